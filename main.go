@@ -14,7 +14,10 @@ import (
 	"gioui.org/widget/material"
 	"gioui.org/x/explorer"
 	"github.com/nmaupu/chesscom_exporter/pkg/api/chesscom"
+	"github.com/nmaupu/chesscom_exporter/pkg/cli"
+	"github.com/nmaupu/chesscom_exporter/pkg/exporter"
 	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
 	mywidget "github.com/nmaupu/chesscom_exporter/pkg/ui/widget"
 	"golang.design/x/clipboard"
 	"image/color"
@@ -22,6 +25,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -57,6 +61,15 @@ var (
 	saveToClipboardBtn = new(widget.Clickable)
 	saveCancelBtn      = new(widget.Clickable)
 
+	saveToFolderBtn    = new(widget.Clickable)
+	exportFolderEditor = &widget.Editor{SingleLine: true}
+	exportLayout       = &widget.Enum{Value: string(exporter.LayoutMonth)}
+
+	exportFormat      = &widget.Enum{Value: string(exporter.FormatPGN)}
+	exportNormalized  = new(widget.Bool)
+	exportLichess     = new(widget.Bool)
+	exportCompression = &widget.Enum{Value: string(exporter.CompressionNone)}
+
 	saveInProgress         bool
 	saveStatus             string
 	saveProgress           float32
@@ -65,6 +78,16 @@ var (
 )
 
 func main() {
+	exporter.Tool = exporter.ToolInfo{Name: AppName, Version: AppVersion, BuildDate: BuildDate}
+
+	// Any argument runs the command line interface instead of the GUI
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	go func() {
 		w := app.NewWindow(
 			app.Title(fmt.Sprintf("%s - %s (%s)", AppName, AppVersion, BuildDate)),
@@ -87,6 +110,10 @@ func draw(w *app.Window) error {
 	// Focusing player's text edit by default
 	usernameLineEditor.Focus()
 
+	if home, err := os.UserHomeDir(); err == nil {
+		exportFolderEditor.SetText(filepath.Join(home, "chesscom-exports"))
+	}
+
 	for {
 		select {
 		case e := <-w.Events():
@@ -123,7 +150,7 @@ func draw(w *app.Window) error {
 
 				if saveToClipboardBtn.Clicked() {
 					go func() { // Go routine to get all checked archives
-						loadAndSaveSelectedArchivesInMemory(func(r io.Reader) error {
+						loadAndSaveSelectedArchivesInMemory(exporter.CompressionNone, func(r io.Reader) error {
 							data, err := ioutil.ReadAll(r)
 							if err != nil {
 								return err
@@ -137,12 +164,21 @@ func draw(w *app.Window) error {
 
 				if saveToFileBtn.Clicked() {
 					username := strings.Trim(usernameLineEditor.Text(), " ")
-					fileWriter, err := explorer.WriteFile(fmt.Sprintf("chesscom-export-%s.pgn", username))
+					compression := exporter.Compression(exportCompression.Value)
+					ext := exporter.Format(exportFormat.Value).Extension() + compression.Extension()
+					if compression == exporter.CompressionZip {
+						ext = compression.Extension()[1:]
+					}
+					fileWriter, err := explorer.WriteFile(fmt.Sprintf("chesscom-export-%s.%s", username, ext))
 					if err != nil {
 						saveStatus = "Not supported, sorry :/"
 					} else {
+						// The extension chosen in the save dialog has precedence if available
+						if f, ok := fileWriter.(interface{ Name() string }); ok {
+							compression = exporter.CompressionFromPath(f.Name())
+						}
 						go func() {
-							loadAndSaveSelectedArchivesInMemory(func(r io.Reader) error {
+							loadAndSaveSelectedArchivesInMemory(compression, func(r io.Reader) error {
 								defer fileWriter.Close()
 								_, err := io.Copy(fileWriter, r)
 								return err
@@ -151,6 +187,27 @@ func draw(w *app.Window) error {
 					}
 				}
 
+				if saveToFolderBtn.Clicked() {
+					dir := strings.TrimSpace(exportFolderEditor.Text())
+					if dir == "" {
+						saveStatus = "Please choose a folder"
+					} else {
+						go loadAndSaveSelectedArchivesToFolder(dir)
+					}
+				}
+
+				if statsBtn.Clicked() {
+					go computeSelectedArchivesStats(w)
+				}
+
+				if ratingsBtn.Clicked() {
+					go computeSelectedArchivesRatings(w)
+				}
+
+				if timeUseBtn.Clicked() {
+					go computeSelectedArchivesTimeUse(w)
+				}
+
 				if saveCancelBtn.Clicked() {
 					if saveInProgress { // button is normally disabled when not in progress though
 						saveProgressCancelChan <- true
@@ -236,6 +293,10 @@ func kitchen(gtx C, th *material.Theme) D {
 		}.Layout(gtx,
 			layout.Rigid(material.ProgressBar(th, saveProgress).Layout),
 			layout.Rigid(layout.Spacer{Height: unit.Dp(2)}.Layout),
+			layout.Rigid(exportOptionsLayout),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(2)}.Layout),
+			layout.Rigid(exportFolderLayout),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(2)}.Layout),
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 				return layout.Flex{
 					Alignment: layout.Middle,
@@ -259,7 +320,8 @@ func kitchen(gtx C, th *material.Theme) D {
 					}),
 					layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
 					layout.Rigid(func(gtx C) D {
-						if saveInProgress || !archiveListWidget.AtLeastOneSelected() {
+						if saveInProgress || !archiveListWidget.AtLeastOneSelected() || exporter.Format(exportFormat.Value).IsBinary() ||
+							exporter.Compression(exportCompression.Value) != exporter.CompressionNone {
 							gtx = gtx.Disabled()
 						}
 						return material.Button(th, saveToClipboardBtn, "Export to clipboard").Layout(gtx)
@@ -276,6 +338,16 @@ func kitchen(gtx C, th *material.Theme) D {
 		)
 	}
 
+	tabContent := layout.Rigid(saveWidget)
+	switch currentTab.Value {
+	case tabStats:
+		tabContent = layout.Flexed(2, statsLayout)
+	case tabRatings:
+		tabContent = layout.Flexed(2, ratingsLayout)
+	case tabTimeUse:
+		tabContent = layout.Flexed(2, timeUseLayout)
+	}
+
 	outerInset := layout.UniformInset(unit.Dp(5))
 	return outerInset.Layout(gtx,
 		func(gtx C) D {
@@ -286,11 +358,104 @@ func kitchen(gtx C, th *material.Theme) D {
 				layout.Rigid(layout.Spacer{Height: unit.Dp(5)}.Layout),
 				layout.Flexed(1, archivesListWidget),
 				layout.Rigid(layout.Spacer{Height: unit.Dp(5)}.Layout),
-				layout.Rigid(saveWidget),
+				layout.Rigid(tabsLayout),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(5)}.Layout),
+				tabContent,
 			)
 		})
 }
 
+func exportOptionsLayout(gtx C) D {
+	if saveInProgress {
+		gtx = gtx.Disabled()
+	}
+
+	children := []layout.FlexChild{
+		layout.Rigid(material.Label(theme, unit.Dp(16), "Format:").Layout),
+	}
+	for _, f := range exporter.Formats {
+		if !f.IsStream() { // only streamable formats can be saved to the clipboard or using the file explorer
+			continue
+		}
+		f := f
+		children = append(children,
+			layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+			layout.Rigid(material.RadioButton(theme, exportFormat, string(f), strings.ToUpper(string(f))).Layout),
+		)
+	}
+
+	children = append(children,
+		layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+		layout.Rigid(func(gtx C) D {
+			if !exporter.Format(exportFormat.Value).SupportsNormalized() {
+				gtx = gtx.Disabled()
+			}
+			return material.CheckBox(theme, exportNormalized, "Normalized records").Layout(gtx)
+		}),
+		layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+		layout.Rigid(func(gtx C) D {
+			if exporter.Format(exportFormat.Value) != exporter.FormatPGN {
+				gtx = gtx.Disabled()
+			}
+			return material.CheckBox(theme, exportLichess, "Lichess PGN").Layout(gtx)
+		}),
+		layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+		layout.Rigid(material.Label(theme, unit.Dp(16), "Compression:").Layout),
+	)
+	for _, c := range exporter.Compressions {
+		c := c
+		children = append(children,
+			layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+			layout.Rigid(material.RadioButton(theme, exportCompression, string(c), strings.Title(string(c))).Layout),
+		)
+	}
+
+	return layout.Flex{
+		Alignment: layout.Middle,
+		Axis:      layout.Horizontal,
+		Spacing:   layout.SpaceStart,
+	}.Layout(gtx, children...)
+}
+
+func exportFolderLayout(gtx C) D {
+	if saveInProgress {
+		gtx = gtx.Disabled()
+	}
+
+	children := []layout.FlexChild{
+		layout.Rigid(material.Label(theme, unit.Dp(16), "Split by:").Layout),
+	}
+	for _, l := range []exporter.Layout{exporter.LayoutMonth, exporter.LayoutYear, exporter.LayoutTimeClass} {
+		l := l
+		children = append(children,
+			layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+			layout.Rigid(material.RadioButton(theme, exportLayout, string(l), strings.Title(strings.ReplaceAll(string(l), "-", " "))).Layout),
+		)
+	}
+	children = append(children,
+		layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+		layout.Flexed(1, func(gtx C) D {
+			e := material.Editor(theme, exportFolderEditor, "Export folder")
+			border := widget.Border{Color: color.NRGBA{A: 0xff}, CornerRadius: unit.Dp(4), Width: unit.Px(1)}
+			return border.Layout(gtx, func(gtx C) D {
+				return layout.UniformInset(unit.Dp(4)).Layout(gtx, e.Layout)
+			})
+		}),
+		layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+		layout.Rigid(func(gtx C) D {
+			if !archiveListWidget.AtLeastOneSelected() {
+				gtx = gtx.Disabled()
+			}
+			return material.Button(theme, saveToFolderBtn, "Export to folder").Layout(gtx)
+		}),
+	)
+
+	return layout.Flex{
+		Alignment: layout.Middle,
+		Axis:      layout.Horizontal,
+	}.Layout(gtx, children...)
+}
+
 func usernameEditorLayout(gtx C, th *material.Theme) D {
 	e := material.Editor(th, usernameLineEditor, "Enter player's name")
 	e.Font.Style = text.Italic
@@ -300,7 +465,42 @@ func usernameEditorLayout(gtx C, th *material.Theme) D {
 	})
 }
 
-func loadAndSaveSelectedArchivesInMemory(saver func(o io.Reader) error) {
+func loadAndSaveSelectedArchivesInMemory(compression exporter.Compression, saver func(o io.Reader) error) {
+	buf := bytes.Buffer{}
+	loadAndSaveSelectedArchives(
+		compression,
+		func(opts exporter.Options) (exporter.GameWriter, error) {
+			layout := exporter.LayoutOptions{Layout: exporter.Layout(exportLayout.Value)}
+			return exporter.NewCompressedGameWriter(&buf, layout, opts)
+		},
+		func(*exporter.Manifest) error {
+			return saver(&buf)
+		},
+	)
+}
+
+func loadAndSaveSelectedArchivesToFolder(dir string) {
+	loadAndSaveSelectedArchives(
+		exporter.Compression(exportCompression.Value),
+		func(opts exporter.Options) (exporter.GameWriter, error) {
+			return exporter.NewSplitWriter(exporter.LayoutOptions{
+				Layout: exporter.Layout(exportLayout.Value),
+				Dir:    dir,
+			}, opts)
+		},
+		func(manifest *exporter.Manifest) error {
+			return manifest.WriteFile(filepath.Join(dir, exporter.ManifestName))
+		},
+	)
+}
+
+// loadAndSaveSelectedArchives fetches all selected archives and writes their games to the writer
+// returned by newGameWriter. saver, if not nil, is called once all games have been written.
+func loadAndSaveSelectedArchives(
+	compression exporter.Compression,
+	newGameWriter func(opts exporter.Options) (exporter.GameWriter, error),
+	saver func(manifest *exporter.Manifest) error,
+) {
 	saveInProgress = true
 	defer func() {
 		saveInProgress = false
@@ -324,11 +524,27 @@ func loadAndSaveSelectedArchivesInMemory(saver func(o io.Reader) error) {
 	}
 	close(ch)
 
-	buf := bytes.Buffer{}
+	opts := exporter.Options{
+		Format:      exporter.Format(exportFormat.Value),
+		Normalized:  exportNormalized.Value,
+		Username:    strings.Trim(usernameLineEditor.Text(), " "),
+		Compression: compression,
+	}
+	if exportLichess.Value {
+		opts.PGN.Profile = pgn.ProfileLichess
+	}
+	manifest := exporter.NewManifest(opts.Username, opts)
+	opts.Manifest = manifest
+	gameWriter, err := newGameWriter(opts)
+	if err != nil {
+		saveStatus = fmt.Sprintf("Error: %v", err)
+		return
+	}
 loop:
 	for {
 		select {
 		case <-saveProgressCancelChan:
+			gameWriter.Close()
 			// Resetting progress
 			saveProgressChan <- 0
 			saveStatus = "Aborted."
@@ -342,10 +558,15 @@ loop:
 				log.Printf("an error occurred trying to get archive %s", e.archive.GetURL())
 				continue
 			}
+			manifest.AddArchive(e.archive)
 
-			// Writing pgn games to the buffer
+			// Writing games
 			for _, game := range res.Games {
-				buf.Write([]byte(game.PGN + "\n"))
+				if err := gameWriter.Write(game); err != nil {
+					log.Printf("an error occurred writing game %s, err=%v", game.URL, err)
+					continue
+				}
+				manifest.AddGame(game)
 			}
 
 			// update progress
@@ -353,11 +574,19 @@ loop:
 		}
 	}
 
-	if err := saver(&buf); err != nil {
+	if err := gameWriter.Close(); err != nil {
 		saveStatus = fmt.Sprintf("Error: %v", err)
 		saveProgressChan <- 0 // resetting progress
 		return
 	}
+
+	if saver != nil {
+		if err := saver(manifest); err != nil {
+			saveStatus = fmt.Sprintf("Error: %v", err)
+			saveProgressChan <- 0 // resetting progress
+			return
+		}
+	}
 	saveProgressChan <- 1
 	saveStatus = "Success !"
 }
@@ -0,0 +1,141 @@
+package book
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/notnil/chess"
+	"testing"
+)
+
+func game(white, black, result, moves string) model.ChesscomGame {
+	return model.ChesscomGame{
+		URL:   "https://www.chess.com/game/live/1",
+		Rules: "chess",
+		PGN:   "[Result \"" + result + "\"]\n\n" + moves + " " + result,
+		White: model.ChesscomPlayerInfo{Username: white},
+		Black: model.ChesscomPlayerInfo{Username: black},
+	}
+}
+
+func TestPolyglotKey(t *testing.T) {
+	// Reference keys from the Polyglot book format specification
+	tests := []struct {
+		moves []string
+		want  uint64
+	}{
+		{moves: nil, want: 0x463b96181691fc9c},
+		{moves: []string{"e4"}, want: 0x823c9b50fd114196},
+		{moves: []string{"e4", "d5"}, want: 0x0756b94461c50fb0},
+		{moves: []string{"e4", "d5", "e5"}, want: 0x662fafb965db29d4},
+		{moves: []string{"e4", "d5", "e5", "f5"}, want: 0x22a48b5a8e47ff78},
+		{moves: []string{"e4", "d5", "e5", "f5", "Ke2"}, want: 0x652a607ca3f242c1},
+		{moves: []string{"e4", "d5", "e5", "f5", "Ke2", "Kf7"}, want: 0x00fdd303c946bdd9},
+		{moves: []string{"a4", "b5", "h4", "b4", "c4"}, want: 0x3c8123ea7b067637},
+		{moves: []string{"a4", "b5", "h4", "b4", "c4", "bxc3", "Ra3"}, want: 0x5c3f9b829b279560},
+	}
+	for _, tt := range tests {
+		pos := chess.StartingPosition()
+		for _, san := range tt.moves {
+			m, err := chess.AlgebraicNotation{}.Decode(pos, san)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pos = pos.Update(m)
+		}
+		if got := PolyglotKey(pos); got != tt.want {
+			t.Errorf("PolyglotKey(%v) = %#016x, want %#016x", tt.moves, got, tt.want)
+		}
+	}
+}
+
+func TestPolyglotMove(t *testing.T) {
+	tests := []struct {
+		fen  string
+		san  string
+		want uint16
+	}{
+		{fen: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", san: "e4", want: 1<<9 | 4<<6 | 3<<3 | 4},
+		{fen: "4k3/8/8/8/8/8/8/4K2R w K - 0 1", san: "O-O", want: 4<<6 | 7},
+		{fen: "r3k3/8/8/8/8/8/8/4K3 b q - 0 1", san: "O-O-O", want: 7<<9 | 4<<6 | 7<<3},
+		{fen: "4k3/P7/8/8/8/8/8/4K3 w - - 0 1", san: "a8=N", want: 1<<12 | 6<<9 | 7<<3},
+	}
+	for _, tt := range tests {
+		pos := &chess.Position{}
+		if err := pos.UnmarshalText([]byte(tt.fen)); err != nil {
+			t.Fatal(err)
+		}
+		m, err := chess.AlgebraicNotation{}.Decode(pos, tt.san)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := PolyglotMove(m); got != tt.want {
+			t.Errorf("PolyglotMove(%s) = %#x, want %#x", tt.san, got, tt.want)
+		}
+	}
+}
+
+func TestTree(t *testing.T) {
+	tree := NewTree(TreeOptions{Username: "erik", Color: model.ColorWhite, MaxPly: 2})
+	games := []struct {
+		game  model.ChesscomGame
+		added bool
+	}{
+		{game: game("erik", "hikaru", "1-0", "1. e4 e5 2. Nf3"), added: true},
+		{game: game("erik", "hikaru", "0-1", "1. e4 c5"), added: true},
+		{game: game("erik", "magnus", "1/2-1/2", "1. d4 d5"), added: true},
+		{game: game("hikaru", "erik", "1-0", "1. e4 e5"), added: false},
+	}
+	for _, g := range games {
+		added, err := tree.Add(g.game)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if added != g.added {
+			t.Errorf("Add() = %v, want %v", added, g.added)
+		}
+	}
+
+	if tree.Root.Games != 3 {
+		t.Errorf("root games = %d, want 3", tree.Root.Games)
+	}
+	e4 := tree.Root.Children[0]
+	if e4.SAN != "e4" || e4.Games != 2 || e4.WhiteWins != 1 || e4.BlackWins != 1 || e4.Score(chess.White) != 0.5 {
+		t.Errorf("unexpected e4 node: %+v", e4.Stats)
+	}
+	if len(e4.Children) != 2 || len(e4.Children[0].Children) != 0 {
+		t.Errorf("tree is not limited to 2 plies")
+	}
+
+	buf := bytes.Buffer{}
+	if err := tree.WriteJSON(&buf, 2); err != nil {
+		t.Fatal(err)
+	}
+	root := jsonNode{}
+	if err := json.Unmarshal(buf.Bytes(), &root); err != nil {
+		t.Fatal(err)
+	}
+	if len(root.Moves) != 1 || root.Moves[0].UCI != "e2e4" || len(root.Moves[0].Moves) != 0 {
+		t.Errorf("unexpected JSON tree: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := tree.WritePolyglot(&buf, PolyglotOptions{Color: chess.White}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 2*16 {
+		t.Fatalf("book size = %d bytes, want 2 entries (e4 and d4)", buf.Len())
+	}
+	if key := binary.BigEndian.Uint64(buf.Bytes()); key != 0x463b96181691fc9c {
+		t.Errorf("entry key = %#x, want the starting position", key)
+	}
+	if weight := binary.BigEndian.Uint16(buf.Bytes()[10:]); weight != 2 {
+		t.Errorf("e4 weight = %d, want 2", weight)
+	}
+
+	entries := tree.PolyglotEntries(PolyglotOptions{Weight: WeightScore})
+	if len(entries) != 5 || entries[0].Key != 0x463b96181691fc9c {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
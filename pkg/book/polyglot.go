@@ -0,0 +1,200 @@
+package book
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/notnil/chess"
+	"io"
+	"sort"
+)
+
+// Weight defines how the weight of Polyglot book entries is computed
+type Weight string
+
+const (
+	// WeightFrequency weights moves by the number of games they have been played in
+	WeightFrequency Weight = "frequency"
+	// WeightScore weights moves by the points scored by the side playing them (2 for a win, 1 for a draw)
+	WeightScore Weight = "score"
+)
+
+// ParseWeight returns the weight named s, an empty string gives WeightFrequency
+func ParseWeight(s string) (Weight, error) {
+	switch Weight(s) {
+	case "", WeightFrequency:
+		return WeightFrequency, nil
+	case WeightScore:
+		return WeightScore, nil
+	}
+	return "", fmt.Errorf("unknown weight %q, expected %s or %s", s, WeightFrequency, WeightScore)
+}
+
+// PolyglotOptions configures the Polyglot book written from a tree
+type PolyglotOptions struct {
+	// MinGames leaves out moves played in less games
+	MinGames int
+	// Weight is how entries are weighted, WeightFrequency if empty
+	Weight Weight
+	// Color only writes moves played by this side (chess.White or chess.Black), both if chess.NoColor
+	Color chess.Color
+}
+
+// PolyglotEntry is a single entry of a Polyglot book
+type PolyglotEntry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+// PolyglotEntries returns the book entries of the tree sorted by key and decreasing weight,
+// moves played from the same position reached through different move orders are merged
+func (t *Tree) PolyglotEntries(opts PolyglotOptions) []PolyglotEntry {
+	type entryKey struct {
+		key  uint64
+		move uint16
+	}
+	weights := make(map[entryKey]int)
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		turn := n.Position.Turn()
+		for _, c := range n.Children {
+			walk(c)
+			if c.Games < opts.MinGames || (opts.Color != chess.NoColor && opts.Color != turn) {
+				continue
+			}
+			weight := c.Games
+			if opts.Weight == WeightScore {
+				wins := c.WhiteWins
+				if turn == chess.Black {
+					wins = c.BlackWins
+				}
+				weight = 2*wins + c.Draws
+			}
+			weights[entryKey{key: PolyglotKey(n.Position), move: PolyglotMove(c.Move)}] += weight
+		}
+	}
+	walk(t.Root)
+
+	maxWeight := 0
+	for _, w := range weights {
+		if w > maxWeight {
+			maxWeight = w
+		}
+	}
+	entries := make([]PolyglotEntry, 0, len(weights))
+	for k, w := range weights {
+		if maxWeight > 0xFFFF { // scale weights down to fit in 16 bits, keeping played moves
+			w = w * 0xFFFF / maxWeight
+			if w == 0 {
+				w = 1
+			}
+		}
+		entries = append(entries, PolyglotEntry{Key: k.key, Move: k.move, Weight: uint16(w)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Key != entries[j].Key {
+			return entries[i].Key < entries[j].Key
+		}
+		if entries[i].Weight != entries[j].Weight {
+			return entries[i].Weight > entries[j].Weight
+		}
+		return entries[i].Move < entries[j].Move
+	})
+	return entries
+}
+
+// WritePolyglot writes the tree as a Polyglot .bin opening book
+func (t *Tree) WritePolyglot(w io.Writer, opts PolyglotOptions) error {
+	buf := make([]byte, 16)
+	for _, e := range t.PolyglotEntries(opts) {
+		binary.BigEndian.PutUint64(buf[0:], e.Key)
+		binary.BigEndian.PutUint16(buf[8:], e.Move)
+		binary.BigEndian.PutUint16(buf[10:], e.Weight)
+		binary.BigEndian.PutUint32(buf[12:], e.Learn)
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// polyglotPieces maps piece types to their Polyglot index, the white piece being the next index
+var polyglotPieces = map[chess.PieceType]int{
+	chess.Pawn:   0,
+	chess.Knight: 2,
+	chess.Bishop: 4,
+	chess.Rook:   6,
+	chess.Queen:  8,
+	chess.King:   10,
+}
+
+// PolyglotKey returns the Polyglot hash of a position
+func PolyglotKey(pos *chess.Position) uint64 {
+	var key uint64
+	board := pos.Board()
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		p := board.Piece(sq)
+		if p == chess.NoPiece {
+			continue
+		}
+		kind := polyglotPieces[p.Type()]
+		if p.Color() == chess.White {
+			kind++
+		}
+		key ^= polyglotRandom64[64*kind+8*int(sq.Rank())+int(sq.File())]
+	}
+
+	cr := pos.CastleRights()
+	for i, c := range []struct {
+		color chess.Color
+		side  chess.Side
+	}{{chess.White, chess.KingSide}, {chess.White, chess.QueenSide}, {chess.Black, chess.KingSide}, {chess.Black, chess.QueenSide}} {
+		if cr.CanCastle(c.color, c.side) {
+			key ^= polyglotRandom64[768+i]
+		}
+	}
+
+	// The en passant file is only hashed if a pawn of the side to move can actually capture
+	if ep := pos.EnPassantSquare(); ep != chess.NoSquare {
+		pawn := chess.WhitePawn
+		rank := ep.Rank() - 1
+		if pos.Turn() == chess.Black {
+			pawn = chess.BlackPawn
+			rank = ep.Rank() + 1
+		}
+		for _, df := range []int{-1, 1} {
+			f := int(ep.File()) + df
+			if f >= 0 && f < 8 && board.Piece(chess.NewSquare(chess.File(f), rank)) == pawn {
+				key ^= polyglotRandom64[772+int(ep.File())]
+				break
+			}
+		}
+	}
+
+	if pos.Turn() == chess.White {
+		key ^= polyglotRandom64[780]
+	}
+	return key
+}
+
+// polyglotPromotions maps promotion pieces to their Polyglot code
+var polyglotPromotions = map[chess.PieceType]uint16{
+	chess.Knight: 1,
+	chess.Bishop: 2,
+	chess.Rook:   3,
+	chess.Queen:  4,
+}
+
+// PolyglotMove encodes a move as a Polyglot book move, castling being encoded as the king taking its own rook
+func PolyglotMove(m *chess.Move) uint16 {
+	from, to := m.S1(), m.S2()
+	switch {
+	case m.HasTag(chess.KingSideCastle):
+		to = chess.NewSquare(chess.FileH, from.Rank())
+	case m.HasTag(chess.QueenSideCastle):
+		to = chess.NewSquare(chess.FileA, from.Rank())
+	}
+	return uint16(to.File()) | uint16(to.Rank())<<3 | uint16(from.File())<<6 | uint16(from.Rank())<<9 |
+		polyglotPromotions[m.Promo()]<<12
+}
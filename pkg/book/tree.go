@@ -0,0 +1,185 @@
+package book
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"github.com/notnil/chess"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DefaultMaxPly is the default number of half-moves of each game added to a tree
+const DefaultMaxPly = 30
+
+// TreeOptions configures which games and moves are aggregated into a tree
+type TreeOptions struct {
+	// Username is the player whose games are aggregated, used along with Color
+	Username string
+	// Color only keeps the games Username played with this color (model.ColorWhite or model.ColorBlack), all games if empty
+	Color string
+	// MaxPly is the number of half-moves of each game added to the tree, DefaultMaxPly if <= 0
+	MaxPly int
+}
+
+// Stats are the results of the games in which a move has been played
+type Stats struct {
+	Games     int `json:"games"`
+	WhiteWins int `json:"white_wins"`
+	Draws     int `json:"draws"`
+	BlackWins int `json:"black_wins"`
+}
+
+// Score returns the score of the given color (1 point for a win, 0.5 for a draw) divided by the number of games
+func (s Stats) Score(color chess.Color) float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	wins := s.WhiteWins
+	if color == chess.Black {
+		wins = s.BlackWins
+	}
+	return (float64(wins) + float64(s.Draws)/2) / float64(s.Games)
+}
+
+func (s *Stats) add(result string) {
+	s.Games++
+	switch result {
+	case "1-0":
+		s.WhiteWins++
+	case "0-1":
+		s.BlackWins++
+	case "1/2-1/2":
+		s.Draws++
+	}
+}
+
+// Node is a position of the tree reached by a sequence of moves, the root being the starting position
+type Node struct {
+	Stats
+	// SAN is the move leading to this position, empty for the root
+	SAN string
+	// Move is the move leading to this position, nil for the root
+	Move *chess.Move
+	// Position is the position reached
+	Position *chess.Position
+	// Children are the positions reached from this one, by order of first appearance
+	Children []*Node
+}
+
+func (n *Node) child(pos *chess.Position, move *chess.Move) *Node {
+	san := chess.AlgebraicNotation{}.Encode(pos, move)
+	for _, c := range n.Children {
+		if c.SAN == san {
+			return c
+		}
+	}
+	c := &Node{SAN: san, Move: move, Position: pos.Update(move)}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// Tree aggregates games into a tree of positions with move frequencies and results
+type Tree struct {
+	Root *Node
+	opts TreeOptions
+}
+
+// NewTree returns an empty tree starting from the standard starting position
+func NewTree(opts TreeOptions) *Tree {
+	if opts.MaxPly <= 0 {
+		opts.MaxPly = DefaultMaxPly
+	}
+	return &Tree{Root: &Node{Position: chess.StartingPosition()}, opts: opts}
+}
+
+// Add aggregates a game into the tree, it returns false if the game is filtered out or cannot be replayed
+// (variants and games not starting from the standard position)
+func (t *Tree) Add(game model.ChesscomGame) (bool, error) {
+	if game.Rules != "" && game.Rules != "chess" {
+		return false, nil
+	}
+	if t.opts.Color != "" && game.PlayerColor(t.opts.Username) != t.opts.Color {
+		return false, nil
+	}
+	g, err := pgn.Parse(game.PGN)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse PGN of %s, err=%v", game.URL, err)
+	}
+	if g.Tag("FEN") != "" {
+		return false, nil
+	}
+	positions, moves, err := g.Replay()
+	if err != nil {
+		return false, fmt.Errorf("unable to replay %s, err=%v", game.URL, err)
+	}
+
+	node := t.Root
+	node.add(g.Result)
+	for i, move := range moves {
+		if i >= t.opts.MaxPly {
+			break
+		}
+		node = node.child(positions[i], move)
+		node.add(g.Result)
+	}
+	return true, nil
+}
+
+// jsonNode is the JSON representation of a node
+type jsonNode struct {
+	SAN        string      `json:"san,omitempty"`
+	UCI        string      `json:"uci,omitempty"`
+	FEN        string      `json:"fen"`
+	Games      int         `json:"games"`
+	WhiteWins  int         `json:"white_wins"`
+	Draws      int         `json:"draws"`
+	BlackWins  int         `json:"black_wins"`
+	WhiteScore float64     `json:"white_score"`
+	BlackScore float64     `json:"black_score"`
+	Moves      []*jsonNode `json:"moves,omitempty"`
+}
+
+func (n *Node) toJSON(minGames int) *jsonNode {
+	j := &jsonNode{
+		SAN:        n.SAN,
+		FEN:        n.Position.String(),
+		Games:      n.Games,
+		WhiteWins:  n.WhiteWins,
+		Draws:      n.Draws,
+		BlackWins:  n.BlackWins,
+		WhiteScore: n.Score(chess.White),
+		BlackScore: n.Score(chess.Black),
+	}
+	if n.Move != nil {
+		j.UCI = chess.UCINotation{}.Encode(nil, n.Move)
+	}
+	for _, c := range n.sortedChildren() {
+		if c.Games >= minGames {
+			j.Moves = append(j.Moves, c.toJSON(minGames))
+		}
+	}
+	return j
+}
+
+// sortedChildren returns the children by decreasing number of games then by SAN
+func (n *Node) sortedChildren() []*Node {
+	children := make([]*Node, len(n.Children))
+	copy(children, n.Children)
+	sort.SliceStable(children, func(i, j int) bool {
+		if children[i].Games != children[j].Games {
+			return children[i].Games > children[j].Games
+		}
+		return strings.Compare(children[i].SAN, children[j].SAN) < 0
+	})
+	return children
+}
+
+// WriteJSON writes the tree as nested JSON objects, moves played in less than minGames games are left out
+func (t *Tree) WriteJSON(w io.Writer, minGames int) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t.Root.toJSON(minGames))
+}
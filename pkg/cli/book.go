@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/book"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/notnil/chess"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func runBook(args []string) error {
+	fs := newFlagSet("book", "<output.bin|output.json|->")
+	username := fs.String("user", "", "chess.com username whose games are aggregated (required)")
+	from := fs.String("from", "", "first month to aggregate (YYYY-MM)")
+	to := fs.String("to", "", "last month to aggregate (YYYY-MM)")
+	color := fs.String("color", "", "only aggregate games played with this color (white or black), the Polyglot book only contains this side's moves")
+	maxPly := fs.Int("max-ply", book.DefaultMaxPly, "number of half-moves of each game to aggregate")
+	minGames := fs.Int("min-games", 1, "leave out moves played in less games")
+	weight := fs.String("weight", "", "Polyglot entries weight: frequency (default) or score")
+	format := fs.String("format", "", "output format: polyglot or json (default: guessed from the output's extension, polyglot otherwise)")
+	filterFlags := addFilterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *username == "" {
+		fs.Usage()
+		return fmt.Errorf("-user is mandatory")
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+	path := fs.Arg(0)
+	if path == "" {
+		path = "-"
+	}
+
+	opts := book.PolyglotOptions{MinGames: *minGames}
+	var err error
+	if opts.Weight, err = book.ParseWeight(*weight); err != nil {
+		return err
+	}
+	switch strings.ToLower(*color) {
+	case "":
+	case model.ColorWhite:
+		opts.Color = chess.White
+	case model.ColorBlack:
+		opts.Color = chess.Black
+	default:
+		return fmt.Errorf("invalid color %q, expected white or black", *color)
+	}
+	if *format == "" {
+		*format = "polyglot"
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			*format = "json"
+		}
+	}
+	if *format != "polyglot" && *format != "json" {
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+
+	filter, err := filterFlags.filter()
+	if err != nil {
+		return err
+	}
+	archives, err := listArchives(*username, *from, *to)
+	if err != nil {
+		return err
+	}
+
+	tree := book.NewTree(book.TreeOptions{Username: *username, Color: strings.ToLower(*color), MaxPly: *maxPly})
+	count := 0
+	err = fetchArchives(archives, func(_ model.ChesscomArchive, games []model.ChesscomGame) error {
+		for _, game := range games {
+			if !filter.match(game) {
+				continue
+			}
+			added, err := tree.Add(game)
+			if err != nil {
+				log.Printf("Skipping game: %v", err)
+				continue
+			}
+			if added {
+				count++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	write := func(w io.Writer) error {
+		if *format == "json" {
+			return tree.WriteJSON(w, *minGames)
+		}
+		return tree.WritePolyglot(w, opts)
+	}
+	if path == "-" {
+		err = write(stdout)
+	} else {
+		err = writeFile(path, write)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("%d games aggregated from %d archives", count, len(archives))
+	return nil
+}
+
+// writeFile creates the file at path and writes it using write
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
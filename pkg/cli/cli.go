@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// command is a sub-command of the command line interface
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+
+	commands []command
+)
+
+func init() {
+	commands = []command{
+		{name: "export", description: "export a player's games to a file", run: runExport},
+		{name: "book", description: "build an opening tree from a player's games as a Polyglot book or JSON", run: runBook},
+		{name: "puzzles", description: "extract the positions in which a player lost material or advantage as a puzzle set", run: runPuzzles},
+		{name: "stats", description: "compute a player's statistics from fetched or exported games", run: runStats},
+		{name: "ratings", description: "extract a player's rating history per time class", run: runRatings},
+		{name: "timeuse", description: "analyze a player's time management from the clocks of live games", run: runTimeUse},
+		{name: "opponents", description: "compute a player's head-to-head records against their opponents", run: runOpponents},
+		{name: "vs", description: "compute the head-to-head record between two players", run: runVs},
+		{name: "verify", description: "verify exported files against their manifest", run: runVerify},
+	}
+}
+
+// Run executes the sub-command given as first argument with the remaining arguments.
+// args must not contain the program name.
+func Run(args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage()
+		return nil
+	}
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			err := c.run(args[1:])
+			if err == flag.ErrHelp {
+				return nil
+			}
+			return err
+		}
+	}
+
+	usage()
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func usage() {
+	fmt.Fprintf(stderr, "Usage: %s <command> [options]\n\n", programName())
+	fmt.Fprintf(stderr, "Without any command, the graphical interface is started.\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(stderr, "  %-12s %s\n", c.name, c.description)
+	}
+	fmt.Fprintf(stderr, "\nRun '%s <command> -h' for more information on a command.\n", programName())
+}
+
+func programName() string {
+	if len(os.Args) == 0 {
+		return "chesscom-exporter"
+	}
+	return os.Args[0]
+}
+
+// newFlagSet returns a flag set for a sub-command, errors are returned and not handled by the flag package
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s %s [options] %s\n\nOptions:\n", programName(), name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
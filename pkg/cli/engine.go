@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/engine"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"runtime"
+	"time"
+)
+
+// engineFlags are the flags configuring the UCI engine analyzing games
+type engineFlags struct {
+	path     *string
+	depth    *int
+	moveTime *time.Duration
+	workers  *int
+	options  *string
+}
+
+// addEngineFlags adds the engine flags to fs, usage describes what the engine is used for
+func addEngineFlags(fs *flag.FlagSet, usage string) *engineFlags {
+	return &engineFlags{
+		path:     fs.String("engine", "", "path of a UCI engine binary "+usage),
+		depth:    fs.Int("engine-depth", 0, fmt.Sprintf("search depth of each position (default %d unless -engine-movetime is set)", engine.DefaultDepth)),
+		moveTime: fs.Duration("engine-movetime", 0, "search time of each position (e.g. 500ms)"),
+		workers:  fs.Int("engine-workers", runtime.NumCPU(), "number of engine processes analyzing positions concurrently"),
+		options:  fs.String("engine-options", "", "comma separated list of UCI options (e.g. Hash=256,Threads=1)"),
+	}
+}
+
+// start starts the engine processes configured by flags, nil is returned if no engine is configured
+func (f *engineFlags) start() (*engine.Pool, error) {
+	if *f.path == "" {
+		return nil, nil
+	}
+	cfg := engine.Config{Path: *f.path, Depth: *f.depth, MoveTime: *f.moveTime}
+	options, err := pgn.ParseTagPairs(*f.options)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range options {
+		if cfg.Options == nil {
+			cfg.Options = make(map[string]string)
+		}
+		cfg.Options[o.Name] = o.Value
+	}
+	return engine.NewPool(cfg, *f.workers)
+}
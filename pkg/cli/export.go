@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/api/chesscom"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"log"
+	"strings"
+	"time"
+)
+
+func runExport(args []string) error {
+	fs := newFlagSet("export", "<output|-|directory>")
+	username := fs.String("user", "", "chess.com username whose games are exported (required)")
+	from := fs.String("from", "", "first month to export (YYYY-MM)")
+	to := fs.String("to", "", "last month to export (YYYY-MM)")
+	filterFlags := addFilterFlags(fs)
+	outFlags := addOutputFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *username == "" {
+		fs.Usage()
+		return fmt.Errorf("-user is mandatory")
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+
+	filter, err := filterFlags.filter()
+	if err != nil {
+		return err
+	}
+	selected, err := listArchives(*username, *from, *to)
+	if err != nil {
+		return err
+	}
+
+	out, err := outFlags.open(fs.Arg(0), *username)
+	if err != nil {
+		return err
+	}
+	out.SetFilter("from", *from)
+	out.SetFilter("to", *to)
+	filterFlags.set(out.SetFilter)
+
+	err = fetchArchives(selected, func(archive model.ChesscomArchive, games []model.ChesscomGame) error {
+		out.AddArchive(archive)
+		for _, game := range games {
+			if !filter.match(game) {
+				continue
+			}
+			if err := out.Write(game); err != nil {
+				return fmt.Errorf("unable to write game %s, err=%v", game.URL, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+	log.Printf("%d games exported from %d archives", out.count, len(selected))
+	return nil
+}
+
+// listArchives returns username's archives between from and to (inclusive), both formatted as YYYY-MM
+func listArchives(username, from, to string) ([]model.ChesscomArchive, error) {
+	archives, err := chesscom.GetAllPlayerArchives(username)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get archives for %s, err=%v", username, err)
+	}
+	return selectArchives(archives.Archives, from, to)
+}
+
+// fetchArchives fetches each archive in turn and calls fn with its games, it stops at the first error
+func fetchArchives(archives []model.ChesscomArchive, fn func(archive model.ChesscomArchive, games []model.ChesscomGame) error) error {
+	for i, archive := range archives {
+		log.Printf("Fetching archive %d/%d: %d-%02d", i+1, len(archives), archive.GetYear(), archive.GetMonth())
+		res, err := chesscom.GetPlayerMonthlyArchivesByURL(archive.GetURL())
+		if err != nil {
+			return fmt.Errorf("unable to get archive %s, err=%v", archive.GetURL(), err)
+		}
+		if err := fn(archive, res.Games); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectArchives returns the archives between from and to (inclusive), both formatted as YYYY-MM.
+// An empty bound is not taken into account.
+func selectArchives(archives []model.ChesscomArchive, from, to string) ([]model.ChesscomArchive, error) {
+	fromIdx, err := monthIndex(from, 0)
+	if err != nil {
+		return nil, err
+	}
+	toIdx, err := monthIndex(to, int(^uint(0)>>1))
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []model.ChesscomArchive
+	for _, a := range archives {
+		idx := a.GetYear()*12 + a.GetMonth() - 1
+		if idx >= fromIdx && idx <= toIdx {
+			selected = append(selected, a)
+		}
+	}
+	return selected, nil
+}
+
+func monthIndex(month string, def int) (int, error) {
+	month = strings.TrimSpace(month)
+	if month == "" {
+		return def, nil
+	}
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return 0, fmt.Errorf("invalid month %q, expected YYYY-MM", month)
+	}
+	return t.Year()*12 + int(t.Month()) - 1, nil
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"github.com/nmaupu/chesscom_exporter/pkg/engine"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"reflect"
+	"testing"
+)
+
+func Test_selectArchives(t *testing.T) {
+	archives := []model.ChesscomArchive{
+		"https://api.chess.com/pub/player/erik/games/2020/12",
+		"https://api.chess.com/pub/player/erik/games/2021/01",
+		"https://api.chess.com/pub/player/erik/games/2021/07",
+	}
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    []model.ChesscomArchive
+		wantErr bool
+	}{
+		{name: "no bounds", want: archives},
+		{name: "from", from: "2021-01", want: archives[1:]},
+		{name: "to", to: "2021-01", want: archives[:2]},
+		{name: "from and to", from: "2021-01", to: "2021-06", want: archives[1:2]},
+		{name: "invalid", from: "2021/01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectArchives(archives, tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectArchives() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectArchives() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseThresholds(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    engine.Thresholds
+		wantErr bool
+	}{
+		{s: "50,100,300", want: engine.Thresholds{Inaccuracy: 50, Mistake: 100, Blunder: 300}},
+		{s: "0, 150, 400", want: engine.Thresholds{Mistake: 150, Blunder: 400}},
+		{s: "50,100", wantErr: true},
+		{s: "50,-1,300", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseThresholds(tt.s)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseThresholds(%q) = %v, %v, want %v, wantErr %v", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
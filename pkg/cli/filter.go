@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"flag"
+	"github.com/nmaupu/chesscom_exporter/pkg/exporter"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/openings"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"strings"
+)
+
+// filterFlags are the flags selecting which games are processed
+type filterFlags struct {
+	eco     *string
+	opening *string
+}
+
+func addFilterFlags(fs *flag.FlagSet) *filterFlags {
+	return &filterFlags{
+		eco:     fs.String("eco", "", "only keep games whose classified ECO code matches one of these comma separated codes, prefixes or ranges (e.g. B20-B99,C4)"),
+		opening: fs.String("opening", "", "only keep games whose classified opening name contains this text, case insensitive (e.g. \"sicilian\")"),
+	}
+}
+
+// gameFilter selects games
+type gameFilter struct {
+	eco     []openings.ECOPattern
+	opening string
+}
+
+func (f *filterFlags) filter() (*gameFilter, error) {
+	eco, err := openings.ParseECOPatterns(*f.eco)
+	if err != nil {
+		return nil, err
+	}
+	return &gameFilter{eco: eco, opening: strings.ToLower(strings.TrimSpace(*f.opening))}, nil
+}
+
+// set records the filters in use
+func (f *filterFlags) set(setFilter func(name, value string)) {
+	setFilter("eco", *f.eco)
+	setFilter("opening", *f.opening)
+}
+
+// match returns true if the game is selected by the filter
+func (f *gameFilter) match(game model.ChesscomGame) bool {
+	if len(f.eco) == 0 && f.opening == "" {
+		return true
+	}
+	g, err := pgn.Parse(game.PGN)
+	if err != nil {
+		return false
+	}
+	opening := exporter.GameOpening(g)
+	return openings.MatchAny(f.eco, opening.ECO) && strings.Contains(strings.ToLower(opening.Name), f.opening)
+}
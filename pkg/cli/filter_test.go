@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"flag"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"testing"
+)
+
+func Test_gameFilter_match(t *testing.T) {
+	sicilian := model.ChesscomGame{PGN: "1. e4 c5 2. Nf3 d6 *"}
+	french := model.ChesscomGame{PGN: "1. e4 e6 *"}
+
+	tests := []struct {
+		name string
+		args []string
+		game model.ChesscomGame
+		want bool
+	}{
+		{name: "no filter", game: sicilian, want: true},
+		{name: "eco range", args: []string{"-eco", "B20-B99"}, game: sicilian, want: true},
+		{name: "eco range excluded", args: []string{"-eco", "B20-B99"}, game: french, want: false},
+		{name: "opening", args: []string{"-opening", "french"}, game: french, want: true},
+		{name: "opening excluded", args: []string{"-opening", "french"}, game: sicilian, want: false},
+		{name: "both", args: []string{"-eco", "C", "-opening", "sicilian"}, game: sicilian, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			ff := addFilterFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			filter, err := ff.filter()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := filter.match(tt.game); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
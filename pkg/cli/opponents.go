@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/report"
+	"log"
+)
+
+func runOpponents(args []string) error {
+	return runHeadToHead("opponents", args, false)
+}
+
+func runVs(args []string) error {
+	return runHeadToHead("vs", args, true)
+}
+
+// runHeadToHead computes the player's records against their opponents,
+// withOpponent adds the -opponent flag restricting games to a single opponent
+func runHeadToHead(name string, args []string, withOpponent bool) error {
+	fs := newFlagSet(name, "[output|-]")
+	source := addSourceFlags(fs)
+	var opponent *string
+	if withOpponent {
+		opponent = fs.String("opponent", "", "chess.com username of the opponent (required)")
+	}
+	top := fs.Int("top", report.DefaultTop, "number of opponents and openings per opponent listed")
+	format := addReportFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+	if withOpponent {
+		if *opponent == "" {
+			fs.Usage()
+			return fmt.Errorf("-opponent is mandatory")
+		}
+		source.opponent = *opponent
+	}
+	f, err := reportFormat(*format, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	h := report.NewHeadToHead(*source.username, *top)
+	added := 0
+	count, err := source.games(func(game model.ChesscomGame) error {
+		ok, err := h.Add(game)
+		if err != nil {
+			log.Printf("Skipping game %s: %v", game.URL, err)
+		}
+		if ok {
+			added++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("%d games read, %d played by %s against %d opponents", count, added, *source.username, len(h.Opponents))
+	return writeReport(fs.Arg(0), h, f)
+}
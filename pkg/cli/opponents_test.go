@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"github.com/nmaupu/chesscom_exporter/pkg/exporter"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunVs_Input(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.json")
+	w, err := exporter.Create(path, exporter.Options{Format: exporter.FormatJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	games := []model.ChesscomGame{
+		{
+			URL: "https://www.chess.com/game/live/1", PGN: "1. e4 c5 *", TimeClass: "blitz",
+			White: model.ChesscomPlayerInfo{Username: "erik", Rating: 1500, Result: "win"},
+			Black: model.ChesscomPlayerInfo{Username: "Hikaru", Rating: 3200, Result: "resigned"},
+		},
+		{
+			URL: "https://www.chess.com/game/live/2", PGN: "1. d4 d5 *", TimeClass: "blitz",
+			White: model.ChesscomPlayerInfo{Username: "magnus", Rating: 2900, Result: "win"},
+			Black: model.ChesscomPlayerInfo{Username: "erik", Rating: 1510, Result: "timeout"},
+		},
+	}
+	for _, g := range games {
+		if err := w.Write(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args     []string
+		contains []string
+		excludes []string
+	}{
+		{
+			args:     []string{"vs", "-user", "erik", "-opponent", "hikaru", "-input", path},
+			contains: []string{"erik vs Hikaru", "Hikaru    1      1  0  0  100.0%  3200"},
+			excludes: []string{"magnus"},
+		},
+		{
+			args:     []string{"opponents", "-user", "erik", "-input", path, "-format", "markdown"},
+			contains: []string{"# Head-to-head records of erik", "| Hikaru | 1 | 1 |", "| magnus | 1 | 0 | 0 | 1 |"},
+		},
+	}
+	for _, tt := range tests {
+		buf := bytes.Buffer{}
+		func() {
+			defer func(w io.Writer) { stdout = w }(stdout)
+			stdout = &buf
+			if err := Run(tt.args); err != nil {
+				t.Fatalf("Run(%v) error = %v", tt.args, err)
+			}
+		}()
+		for _, c := range tt.contains {
+			if !strings.Contains(buf.String(), c) {
+				t.Errorf("Run(%v) output does not contain %q:\n%s", tt.args, c, buf.String())
+			}
+		}
+		for _, c := range tt.excludes {
+			if strings.Contains(buf.String(), c) {
+				t.Errorf("Run(%v) output contains %q:\n%s", tt.args, c, buf.String())
+			}
+		}
+	}
+
+	if err := Run([]string{"vs", "-user", "erik", "-input", path}); err == nil {
+		t.Errorf("Run() without -opponent should fail")
+	}
+}
+
+func TestCommonArchives(t *testing.T) {
+	archive := func(user, month string) model.ChesscomArchive {
+		return model.ChesscomArchive("https://api.chess.com/pub/player/" + user + "/games/" + month)
+	}
+	archives := []model.ChesscomArchive{archive("erik", "2021/05"), archive("erik", "2021/06"), archive("erik", "2021/07")}
+	others := []model.ChesscomArchive{archive("hikaru", "2021/07"), archive("hikaru", "2021/05"), archive("hikaru", "2020/06")}
+
+	got := commonArchives(archives, others)
+	want := []model.ChesscomArchive{archive("erik", "2021/05"), archive("erik", "2021/07")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("commonArchives() = %v, want %v", got, want)
+	}
+}
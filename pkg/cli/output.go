@@ -0,0 +1,325 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/engine"
+	"github.com/nmaupu/chesscom_exporter/pkg/exporter"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// outputFlags are the flags configuring how games are written, shared by all commands exporting games
+type outputFlags struct {
+	format      *string
+	normalized  *bool
+	columns     *string
+	layout      *string
+	template    *string
+	maxGames    *int
+	compression *string
+	movesOutput *string
+	manifest    *bool
+	positions   *string
+	openingPly  *int
+
+	profile       *string
+	stripComments *bool
+	stripClocks   *bool
+	dropTags      *string
+	renameTags    *string
+	addTags       *string
+	strFirst      *bool
+	wrap          *int
+
+	engine        *engineFlags
+	nagThresholds *string
+}
+
+func addOutputFlags(fs *flag.FlagSet) *outputFlags {
+	return &outputFlags{
+		format:      fs.String("format", "", "output format: pgn, json, ndjson, csv, sqlite, parquet, epd or fen (default: guessed from the output's extension, pgn otherwise)"),
+		normalized:  fs.Bool("normalized", false, "write normalized records instead of raw games (json and ndjson only)"),
+		columns:     fs.String("columns", "", "comma separated list of CSV columns"),
+		layout:      fs.String("layout", "", "split games across files in the output directory: single, month, year, time-class or chunk"),
+		template:    fs.String("template", "", "file name template used with -layout, relative to the output directory (e.g. {{.User}}/{{.Year}}-{{.Month}}.{{.Ext}})"),
+		maxGames:    fs.Int("max-games", 0, "maximum number of games per file for the chunk layout"),
+		compression: fs.String("compression", "", "output compression: none, gzip, zstd or zip (default: guessed from the output's extension), zip packs files split by -layout (month by default) and a manifest in a single archive"),
+		movesOutput: fs.String("moves-output", "", "parquet only, path of a second file receiving one row per move with clocks"),
+		manifest:    fs.Bool("manifest", true, "write a manifest with checksums along with the exported files"),
+		positions:   fs.String("positions", "", "epd and fen only, comma separated list of positions to write for each game: every-move, opening, blunder or final (default final)"),
+		openingPly:  fs.Int("opening-ply", exporter.DefaultOpeningPly, "epd and fen only, number of half-moves after which the opening position is taken"),
+
+		profile:       fs.String("profile", "", "PGN profile: chesscom (default) or lichess, converting chess.com specific tags and clocks for Lichess and other tools"),
+		stripComments: fs.Bool("strip-comments", false, "remove all comments (clocks included) from the PGN movetext"),
+		stripClocks:   fs.Bool("strip-clocks", false, "remove [%clk] clock annotations from the PGN movetext"),
+		dropTags:      fs.String("drop-tags", "", "comma separated list of PGN tags to remove (e.g. CurrentPosition,ECOUrl)"),
+		renameTags:    fs.String("rename-tags", "", "comma separated list of PGN tags to rename (e.g. Link=Site)"),
+		addTags:       fs.String("add-tags", "", "comma separated list of PGN tags to add or replace (e.g. Annotator=coach)"),
+		strFirst:      fs.Bool("str-first", false, "write the PGN Seven Tag Roster first"),
+		wrap:          fs.Int("wrap", 0, "wrap the PGN movetext at this number of columns (e.g. 80)"),
+
+		engine:        addEngineFlags(fs, "analyzing games, evaluations are written as [%eval] comments with NAGs for inaccuracies, mistakes and blunders"),
+		nagThresholds: fs.String("nag-thresholds", fmt.Sprintf("%d,%d,%d", engine.DefaultInaccuracy, engine.DefaultMistake, engine.DefaultBlunder), "centipawn losses from which moves are annotated as inaccuracies, mistakes and blunders"),
+	}
+}
+
+// output is an opened output games are written to
+type output struct {
+	exporter.GameWriter
+	path     string
+	moves    string
+	split    bool
+	manifest *exporter.Manifest
+	pool     *engine.Pool
+	count    int
+}
+
+// open opens the output at path ("-" for stdout, a directory when a layout is used)
+// to write username's games
+func (f *outputFlags) open(path string, username string) (*output, error) {
+	if path == "" {
+		path = "-"
+		if *f.layout != "" {
+			path = "."
+		}
+	}
+	guess := path != "-" && *f.layout == ""
+
+	opts := exporter.Options{
+		Normalized: *f.normalized,
+		Username:   username,
+		MovesPath:  *f.movesOutput,
+		OpeningPly: *f.openingPly,
+	}
+	var err error
+	switch {
+	case *f.format != "":
+		opts.Format, err = exporter.ParseFormat(*f.format)
+	case guess:
+		opts.Format = exporter.FormatFromPath(path)
+	default:
+		opts.Format = exporter.FormatPGN
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.Columns, err = exporter.ParseCSVColumns(*f.columns); err != nil {
+		return nil, err
+	}
+	if opts.Positions, err = exporter.ParsePositionPoints(*f.positions); err != nil {
+		return nil, err
+	}
+	if opts.PGN, err = f.pgnOptions(); err != nil {
+		return nil, err
+	}
+	switch {
+	case *f.compression != "":
+		opts.Compression, err = exporter.ParseCompression(*f.compression)
+	case guess:
+		opts.Compression = exporter.CompressionFromPath(path)
+	default:
+		opts.Compression = exporter.CompressionNone
+	}
+	if err != nil {
+		return nil, err
+	}
+	layout := exporter.LayoutOptions{Dir: path, Template: *f.template, MaxGames: *f.maxGames, Layout: exporter.LayoutMonth}
+	if *f.layout != "" {
+		if layout.Layout, err = exporter.ParseLayout(*f.layout); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Thresholds, err = parseThresholds(*f.nagThresholds); err != nil {
+		return nil, err
+	}
+	if opts.Engine, err = f.engine.start(); err != nil {
+		return nil, err
+	}
+
+	o := &output{path: path, moves: opts.MovesPath, pool: opts.Engine, split: *f.layout != "" && opts.Compression != exporter.CompressionZip}
+	if *f.manifest && path != "-" {
+		o.manifest = exporter.NewManifest(username, opts)
+		opts.Manifest = o.manifest
+	}
+
+	switch {
+	case opts.Compression == exporter.CompressionZip:
+		o.GameWriter, err = createZipBundle(path, layout, opts)
+	case o.split:
+		o.GameWriter, err = exporter.NewSplitWriter(layout, opts)
+	case path == "-":
+		o.GameWriter, err = exporter.NewCompressedGameWriter(stdout, layout, opts)
+	default:
+		o.GameWriter, err = exporter.Create(path, opts)
+	}
+	if err != nil {
+		o.closeEngine()
+		return nil, err
+	}
+	return o, nil
+}
+
+// parseThresholds parses comma separated inaccuracy, mistake and blunder centipawn losses
+func parseThresholds(s string) (engine.Thresholds, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return engine.Thresholds{}, fmt.Errorf("invalid NAG thresholds %q, expected inaccuracy,mistake,blunder", s)
+	}
+	values := make([]int, len(parts))
+	for i, p := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || v < 0 {
+			return engine.Thresholds{}, fmt.Errorf("invalid NAG threshold %q", p)
+		}
+		values[i] = v
+	}
+	return engine.Thresholds{Inaccuracy: values[0], Mistake: values[1], Blunder: values[2]}, nil
+}
+
+// closeEngine quits the engine processes, if any
+func (o *output) closeEngine() {
+	if o.pool != nil {
+		if err := o.pool.Close(); err != nil {
+			log.Printf("Engine exited with an error: %v", err)
+		}
+	}
+}
+
+// pgnOptions returns the PGN rewriting options configured by flags
+func (f *outputFlags) pgnOptions() (pgn.NormalizeOptions, error) {
+	opts := pgn.NormalizeOptions{
+		StripComments:       *f.stripComments,
+		StripClocks:         *f.stripClocks,
+		DropTags:            pgn.ParseTagList(*f.dropTags),
+		SevenTagRosterFirst: *f.strFirst,
+		LineWidth:           *f.wrap,
+	}
+	var err error
+	if opts.Profile, err = pgn.ParseProfile(*f.profile); err != nil {
+		return opts, err
+	}
+	renames, err := pgn.ParseTagPairs(*f.renameTags)
+	if err != nil {
+		return opts, err
+	}
+	for _, t := range renames {
+		if opts.RenameTags == nil {
+			opts.RenameTags = make(map[string]string)
+		}
+		opts.RenameTags[t.Name] = t.Value
+	}
+	opts.AddTags, err = pgn.ParseTagPairs(*f.addTags)
+	return opts, err
+}
+
+// AddArchive records an archive whose games are written to the output
+func (o *output) AddArchive(archive model.ChesscomArchive) {
+	if o.manifest != nil {
+		o.manifest.AddArchive(archive)
+	}
+}
+
+// SetFilter records a filter applied to the exported games
+func (o *output) SetFilter(name, value string) {
+	if o.manifest != nil {
+		o.manifest.SetFilter(name, value)
+	}
+}
+
+func (o *output) Write(game model.ChesscomGame) error {
+	if err := o.GameWriter.Write(game); err != nil {
+		return err
+	}
+	o.count++
+	if o.manifest != nil {
+		o.manifest.AddGame(game)
+	}
+	return nil
+}
+
+// Close closes the output and writes its manifest: in the output directory for split outputs,
+// next to the output file otherwise (zip bundles embed their own manifest)
+func (o *output) Close() error {
+	defer o.closeEngine()
+	if err := o.GameWriter.Close(); err != nil {
+		return err
+	}
+	if o.manifest == nil || o.manifest.Compression == exporter.CompressionZip {
+		return nil
+	}
+
+	manifestPath := filepath.Join(o.path, exporter.ManifestName)
+	if !o.split {
+		dir := filepath.Dir(o.path)
+		if err := o.manifest.AddFile(dir, filepath.Base(o.path), o.count); err != nil {
+			return err
+		}
+		if o.moves != "" {
+			rel, err := relativePath(dir, o.moves)
+			if err != nil {
+				return err
+			}
+			if err := o.manifest.AddFile(dir, rel, o.count); err != nil {
+				return err
+			}
+		}
+		manifestPath = o.path + exporter.ManifestSuffix
+	}
+	if err := o.manifest.WriteFile(manifestPath); err != nil {
+		return err
+	}
+	log.Printf("Manifest written to %s", manifestPath)
+	return nil
+}
+
+// relativePath returns path relative to dir, both being relative to the working directory or absolute
+func relativePath(dir, path string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(absDir, absPath)
+}
+
+// createZipBundle returns a GameWriter writing a zip bundle to path (stdout if "-")
+func createZipBundle(path string, layout exporter.LayoutOptions, opts exporter.Options) (exporter.GameWriter, error) {
+	if path == "-" {
+		return exporter.NewZipBundleWriter(stdout, layout, opts)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := exporter.NewZipBundleWriter(f, layout, opts)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &closingGameWriter{GameWriter: w, closer: f}, nil
+}
+
+// closingGameWriter closes an additional resource once the GameWriter is closed
+type closingGameWriter struct {
+	exporter.GameWriter
+	closer io.Closer
+}
+
+func (c *closingGameWriter) Close() error {
+	err := c.GameWriter.Close()
+	if e := c.closer.Close(); err == nil {
+		err = e
+	}
+	return err
+}
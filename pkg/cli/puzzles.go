@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/puzzle"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+func runPuzzles(args []string) error {
+	fs := newFlagSet("puzzles", "[output|-]")
+	source := addSourceFlags(fs)
+	engineFlags := addEngineFlags(fs, "analyzing games (required)")
+	minLoss := fs.Int("min-loss", puzzle.DefaultMinLoss, "centipawns the player must have lost for a position to give a puzzle")
+	format := fs.String("format", "", "puzzle format: pgn (positions as FEN setup games) or epd (default: guessed from the output's extension, pgn otherwise)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+	if *engineFlags.path == "" {
+		fs.Usage()
+		return fmt.Errorf("-engine is mandatory")
+	}
+	path := fs.Arg(0)
+	if *format == "" && strings.EqualFold(filepath.Ext(path), ".epd") {
+		*format = string(puzzle.FormatEPD)
+	}
+	f, err := puzzle.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	pool, err := engineFlags.start()
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	count, puzzles := 0, 0
+	write := func(w io.Writer) error {
+		count, err = source.games(func(game model.ChesscomGame) error {
+			extracted, err := puzzle.Extract(game, *source.username, pool, *minLoss)
+			if err != nil {
+				log.Printf("Skipping game %s: %v", game.URL, err)
+				return nil
+			}
+			for _, p := range extracted {
+				if err := puzzle.Write(w, p, f); err != nil {
+					return err
+				}
+			}
+			puzzles += len(extracted)
+			return nil
+		})
+		return err
+	}
+	if path == "" || path == "-" {
+		err = write(stdout)
+	} else {
+		err = writeFile(path, write)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("%d puzzles extracted from %d games", puzzles, count)
+	return nil
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"github.com/nmaupu/chesscom_exporter/pkg/engine/enginetest"
+	"github.com/nmaupu/chesscom_exporter/pkg/exporter"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	enginetest.Main()
+	os.Exit(m.Run())
+}
+
+func TestRunPuzzles_Input(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "games.ndjson")
+	w, err := exporter.Create(input, exporter.Options{Format: exporter.FormatNDJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	game := model.ChesscomGame{
+		URL: "https://www.chess.com/game/live/1", PGN: "1. e4 d5 2. Qg4 Bxg4 0-1", Rules: "chess",
+		White: model.ChesscomPlayerInfo{Username: "erik", Result: "resigned"},
+		Black: model.ChesscomPlayerInfo{Username: "hikaru", Result: "win"},
+	}
+	if err := w.Write(game); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	engine, args := enginetest.Command()
+	output := filepath.Join(dir, "puzzles.epd")
+	err = Run([]string{"puzzles", "-user", "erik", "-input", input, "-engine", engine, "-engine-options", "Threads=1",
+		"-engine-depth", "1", "-engine-workers", "1", output})
+	if err == nil {
+		t.Fatalf("Run() should fail, the fake engine needs its argument")
+	}
+
+	// The fake engine argument cannot be given through flags, the engine is started through a script
+	script := filepath.Join(dir, "engine.sh")
+	if err := ioutil.WriteFile(script, []byte("#!/bin/sh\nexec "+engine+" "+strings.Join(args, " ")+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run([]string{"puzzles", "-user", "erik", "-input", input, "-engine", script, "-engine-depth", "1", output}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	data, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 bm exd5; am Qg4;") {
+		t.Errorf("unexpected puzzles:\n%s", data)
+	}
+}
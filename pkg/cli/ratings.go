@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/report"
+	"log"
+)
+
+func runRatings(args []string) error {
+	fs := newFlagSet("ratings", "[output|-]")
+	source := addSourceFlags(fs)
+	sampling := fs.String("sampling", "", "rating history downsampling: game, day or week (default day)")
+	format := addReportFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+	f, err := reportFormat(*format, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	s, err := report.ParseSampling(*sampling)
+	if err != nil {
+		return err
+	}
+
+	history := report.NewRatingHistory(*source.username, s)
+	added := 0
+	count, err := source.games(func(game model.ChesscomGame) error {
+		if history.Add(game) {
+			added++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("%d games read, %d played by %s", count, added, *source.username)
+	return writeReport(fs.Arg(0), history, f)
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/exporter"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"strings"
+)
+
+// sourceFlags are the flags selecting the games a report is computed from:
+// either fetched from chess.com or read from previous json/ndjson exports
+type sourceFlags struct {
+	username *string
+	from     *string
+	to       *string
+	input    *string
+	filter   *filterFlags
+	// opponent restricts games to those played against this player when not empty
+	opponent string
+}
+
+func addSourceFlags(fs *flag.FlagSet) *sourceFlags {
+	return &sourceFlags{
+		username: fs.String("user", "", "chess.com username the report is computed for (required)"),
+		from:     fs.String("from", "", "first month of games to fetch (YYYY-MM)"),
+		to:       fs.String("to", "", "last month of games to fetch (YYYY-MM)"),
+		input:    fs.String("input", "", "comma separated list of json or ndjson exports to read games from instead of fetching them"),
+		filter:   addFilterFlags(fs),
+	}
+}
+
+// games calls fn with each selected game and returns the number of games selected
+func (s *sourceFlags) games(fn func(game model.ChesscomGame) error) (int, error) {
+	if *s.username == "" {
+		return 0, fmt.Errorf("-user is mandatory")
+	}
+	filter, err := s.filter.filter()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	selected := func(game model.ChesscomGame) error {
+		if !filter.match(game) || !s.against(game) {
+			return nil
+		}
+		count++
+		return fn(game)
+	}
+
+	if *s.input != "" {
+		for _, path := range strings.Split(*s.input, ",") {
+			if err := exporter.ReadGamesFile(strings.TrimSpace(path), selected); err != nil {
+				return count, fmt.Errorf("unable to read %s, err=%v", path, err)
+			}
+		}
+		return count, nil
+	}
+
+	var archives []model.ChesscomArchive
+	if s.opponent != "" {
+		archives, err = listCommonArchives(*s.username, s.opponent, *s.from, *s.to)
+	} else {
+		archives, err = listArchives(*s.username, *s.from, *s.to)
+	}
+	if err != nil {
+		return 0, err
+	}
+	err = fetchArchives(archives, func(_ model.ChesscomArchive, games []model.ChesscomGame) error {
+		for _, game := range games {
+			if err := selected(game); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// against returns true if the game was played against the opponent or if no opponent is set
+func (s *sourceFlags) against(game model.ChesscomGame) bool {
+	if s.opponent == "" {
+		return true
+	}
+	color := game.PlayerColor(*s.username)
+	return color != "" && strings.EqualFold(game.Opponent(color).Username, s.opponent)
+}
+
+// listCommonArchives returns username's archives of the months opponent also played games,
+// only those can contain games between the two players
+func listCommonArchives(username, opponent, from, to string) ([]model.ChesscomArchive, error) {
+	archives, err := listArchives(username, from, to)
+	if err != nil {
+		return nil, err
+	}
+	opponentArchives, err := listArchives(opponent, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return commonArchives(archives, opponentArchives), nil
+}
+
+// commonArchives returns the archives of the months found in both lists
+func commonArchives(archives, others []model.ChesscomArchive) []model.ChesscomArchive {
+	months := make(map[int]bool, len(others))
+	for _, a := range others {
+		months[a.GetYear()*12+a.GetMonth()-1] = true
+	}
+	var common []model.ChesscomArchive
+	for _, a := range archives {
+		if months[a.GetYear()*12+a.GetMonth()-1] {
+			common = append(common, a)
+		}
+	}
+	return common
+}
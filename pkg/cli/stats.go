@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/report"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+func runStats(args []string) error {
+	fs := newFlagSet("stats", "[output|-]")
+	source := addSourceFlags(fs)
+	top := fs.Int("top", report.DefaultTop, "number of openings and best wins listed")
+	format := addReportFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+	f, err := reportFormat(*format, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	stats := report.NewStats(*source.username, *top)
+	count, err := source.games(func(game model.ChesscomGame) error {
+		if _, err := stats.Add(game); err != nil {
+			log.Printf("Skipping game %s: %v", game.URL, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("%d games read, %d played by %s", count, stats.Overall.Games, *source.username)
+	return writeReport(fs.Arg(0), stats, f)
+}
+
+func addReportFormatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", "", "report format: text, json, markdown or csv when available (default: guessed from the output's extension, text otherwise)")
+}
+
+// reportFormat returns the report format named format, guessed from path if empty
+func reportFormat(format, path string) (report.Format, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			return report.FormatJSON, nil
+		case ".md", ".markdown":
+			return report.FormatMarkdown, nil
+		case ".csv":
+			return report.FormatCSV, nil
+		}
+	}
+	return report.ParseFormat(format)
+}
+
+// writeReport renders the report to path, stdout if empty or "-"
+func writeReport(path string, r report.Report, format report.Format) error {
+	if path == "" || path == "-" {
+		return report.Write(stdout, r, format)
+	}
+	return writeFile(path, func(w io.Writer) error {
+		return report.Write(w, r, format)
+	})
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"github.com/nmaupu/chesscom_exporter/pkg/exporter"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunStats_Input(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.ndjson")
+	w, err := exporter.Create(path, exporter.Options{Format: exporter.FormatNDJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	games := []model.ChesscomGame{
+		{
+			URL: "https://www.chess.com/game/live/1", PGN: "1. e4 c5 *", TimeClass: "blitz",
+			White: model.ChesscomPlayerInfo{Username: "erik", Rating: 1500, Result: "win"},
+			Black: model.ChesscomPlayerInfo{Username: "hikaru", Rating: 3200, Result: "resigned"},
+		},
+		{
+			URL: "https://www.chess.com/game/live/2", PGN: "1. d4 d5 *", TimeClass: "blitz",
+			White: model.ChesscomPlayerInfo{Username: "erik", Rating: 1510, Result: "timeout"},
+			Black: model.ChesscomPlayerInfo{Username: "hikaru", Rating: 3200, Result: "win"},
+		},
+	}
+	for _, g := range games {
+		if err := w.Write(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.Buffer{}
+	defer func(w io.Writer) { stdout = w }(stdout)
+	stdout = &buf
+
+	if err := Run([]string{"stats", "-user", "erik", "-input", path, "-eco", "B", "-format", "markdown"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "| All games | 1 | 1 | 0 | 0 | 100.0% | 3200 | 3600 | - |") {
+		t.Errorf("unexpected report:\n%s", buf.String())
+	}
+}
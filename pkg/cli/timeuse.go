@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/report"
+	"log"
+)
+
+func runTimeUse(args []string) error {
+	fs := newFlagSet("timeuse", "[output|-]")
+	source := addSourceFlags(fs)
+	format := addReportFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+	f, err := reportFormat(*format, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	tu := report.NewTimeUse(*source.username)
+	count, err := source.games(func(game model.ChesscomGame) error {
+		if _, err := tu.Add(game); err != nil {
+			log.Printf("Skipping game %s: %v", game.URL, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("%d games read, %d live games with clocks played by %s", count, len(tu.Games), *source.username)
+	return writeReport(fs.Arg(0), tu, f)
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/exporter"
+)
+
+func runVerify(args []string) error {
+	fs := newFlagSet("verify", "<manifest|directory|bundle.zip>")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("a manifest, a directory or a zip bundle is expected")
+	}
+
+	results, err := exporter.VerifyManifest(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(stdout, "FAILED %s: %v\n", r.Path, r.Err)
+			continue
+		}
+		fmt.Fprintf(stdout, "OK     %s\n", r.Path)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d files failed verification", failed, len(results))
+	}
+	fmt.Fprintf(stdout, "%d files verified\n", len(results))
+	return nil
+}
@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"strings"
+)
+
+// Move quality NAGs
+const (
+	NAGMistake    = "$2"
+	NAGBlunder    = "$4"
+	NAGInaccuracy = "$6"
+)
+
+// Default centipawn losses from which moves are annotated
+const (
+	DefaultInaccuracy = 50
+	DefaultMistake    = 100
+	DefaultBlunder    = 300
+)
+
+// maxLoss bounds evaluations when computing losses so that mates do not dominate averages
+const maxLoss = 1000
+
+// Thresholds are the centipawn losses from which moves are annotated as inaccuracies, mistakes and blunders
+type Thresholds struct {
+	Inaccuracy int
+	Mistake    int
+	Blunder    int
+}
+
+// DefaultThresholds returns the default thresholds
+func DefaultThresholds() Thresholds {
+	return Thresholds{Inaccuracy: DefaultInaccuracy, Mistake: DefaultMistake, Blunder: DefaultBlunder}
+}
+
+// NAG returns the NAG of a move losing loss centipawns, an empty string for good moves
+func (t Thresholds) NAG(loss int) string {
+	switch {
+	case t.Blunder > 0 && loss >= t.Blunder:
+		return NAGBlunder
+	case t.Mistake > 0 && loss >= t.Mistake:
+		return NAGMistake
+	case t.Inaccuracy > 0 && loss >= t.Inaccuracy:
+		return NAGInaccuracy
+	}
+	return ""
+}
+
+// Loss returns the centipawns lost by the side playing the move between evaluations before and after,
+// both from white's point of view. Evaluations are bounded to ±1000 and the loss is never negative.
+func Loss(before, after Score, white bool) int {
+	b, a := bound(before.Centipawns()), bound(after.Centipawns())
+	loss := b - a
+	if !white {
+		loss = a - b
+	}
+	if loss < 0 {
+		return 0
+	}
+	return loss
+}
+
+func bound(cp int) int {
+	if cp > maxLoss {
+		return maxLoss
+	}
+	if cp < -maxLoss {
+		return -maxLoss
+	}
+	return cp
+}
+
+// Annotate writes evaluations as [%eval] comments after each move and replaces move quality NAGs
+// according to thresholds. evals are the evaluations of the starting position and of the position
+// after each move, from white's point of view, as returned by Pool.AnalyzeGame.
+func Annotate(g *pgn.Game, evals []Eval, thresholds Thresholds) error {
+	if len(evals) != len(g.Moves)+1 {
+		return fmt.Errorf("%d evaluations for %d moves", len(evals), len(g.Moves))
+	}
+	for i := range g.Moves {
+		m := &g.Moves[i]
+		after := evals[i+1]
+
+		comment := strings.TrimSpace(evalRegexp.ReplaceAllString(m.Comment, ""))
+		if !after.Terminal {
+			eval := fmt.Sprintf("[%%eval %s]", after.Score)
+			if comment == "" {
+				comment = eval
+			} else {
+				comment = eval + " " + comment
+			}
+		}
+		m.Comment = comment
+
+		var nags []string
+		for _, nag := range m.NAGs {
+			if nag != NAGInaccuracy && nag != NAGMistake && nag != NAGBlunder {
+				nags = append(nags, nag)
+			}
+		}
+		if nag := thresholds.NAG(Loss(evals[i].Score, after.Score, m.White)); nag != "" {
+			nags = append(nags, nag)
+		}
+		m.NAGs = nags
+	}
+	return nil
+}
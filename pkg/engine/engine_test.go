@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"github.com/nmaupu/chesscom_exporter/pkg/engine/enginetest"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	enginetest.Main()
+	os.Exit(m.Run())
+}
+
+func fakeConfig() Config {
+	path, args := enginetest.Command()
+	return Config{Path: path, Args: args, Depth: 1, Options: map[string]string{"Hash": "16"}}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		s          string
+		score      Score
+		centipawns int
+	}{
+		{s: "0.35", score: Score{CP: 35}, centipawns: 35},
+		{s: "-1.20", score: Score{CP: -120}, centipawns: -120},
+		{s: "#3", score: Score{Mate: 3}, centipawns: MateScore - 3},
+		{s: "#-2", score: Score{Mate: -2}, centipawns: -MateScore + 2},
+	}
+	for _, tt := range tests {
+		got, err := ParseScore(tt.s)
+		if err != nil || got != tt.score {
+			t.Errorf("ParseScore(%q) = %v, %v, want %v", tt.s, got, err, tt.score)
+		}
+		if got.String() != tt.s {
+			t.Errorf("%v.String() = %q, want %q", got, got.String(), tt.s)
+		}
+		if got.Centipawns() != tt.centipawns {
+			t.Errorf("%v.Centipawns() = %d, want %d", got, got.Centipawns(), tt.centipawns)
+		}
+	}
+
+	if s, ok := CommentScore("[%clk 0:02:59] [%eval -0.50,12]"); !ok || s.CP != -50 {
+		t.Errorf("CommentScore() = %v, %v", s, ok)
+	}
+	if _, ok := CommentScore("[%clk 0:02:59]"); ok {
+		t.Errorf("CommentScore() without eval should not be ok")
+	}
+}
+
+func TestConfig_goCommand(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{cfg: Config{}, want: "go depth 15"},
+		{cfg: Config{Depth: 20}, want: "go depth 20"},
+		{cfg: Config{MoveTime: 1500000000}, want: "go movetime 1500"},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.goCommand(); got != tt.want {
+			t.Errorf("goCommand() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestPool_AnalyzeGame(t *testing.T) {
+	pool, err := NewPool(fakeConfig(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	tests := []struct {
+		name     string
+		pgn      string
+		comments []string
+		nags     [][]string
+	}{
+		{
+			name:     "blunder",
+			pgn:      "1. e4 {[%clk 0:02:59]} d5 2. Qg4 $1 Bxg4 *",
+			comments: []string{"[%eval 0.00] [%clk 0:02:59]", "[%eval 1.00]", "[%eval -9.00]", "[%eval -8.00]"},
+			nags:     [][]string{nil, {NAGMistake}, {"$1", NAGBlunder}, {NAGMistake}},
+		},
+		{
+			name:     "checkmate",
+			pgn:      "1. f3 e5 2. g4 Qh4# 0-1",
+			comments: []string{"[%eval 0.00]", "[%eval 0.00]", "[%eval 0.00]", ""},
+			nags:     [][]string{nil, nil, nil, nil},
+		},
+	}
+	for _, tt := range tests {
+		g, err := pgn.Parse(tt.pgn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		evals, err := pool.AnalyzeGame(g)
+		if err != nil {
+			t.Fatalf("%s: AnalyzeGame() error = %v", tt.name, err)
+		}
+		if err := Annotate(g, evals, DefaultThresholds()); err != nil {
+			t.Fatal(err)
+		}
+		for i, m := range g.Moves {
+			if m.Comment != tt.comments[i] {
+				t.Errorf("%s: move %s comment = %q, want %q", tt.name, m.SAN, m.Comment, tt.comments[i])
+			}
+			if !reflect.DeepEqual(m.NAGs, tt.nags[i]) {
+				t.Errorf("%s: move %s NAGs = %v, want %v", tt.name, m.SAN, m.NAGs, tt.nags[i])
+			}
+		}
+	}
+}
+
+func TestStart_Errors(t *testing.T) {
+	if _, err := Start(Config{}); err == nil {
+		t.Errorf("Start() without path should fail")
+	}
+	if _, err := Start(Config{Path: "/nonexistent/engine"}); err == nil {
+		t.Errorf("Start() with a missing binary should fail")
+	}
+}
+
+func TestThresholds_NAG(t *testing.T) {
+	tests := []struct {
+		loss int
+		want string
+	}{
+		{loss: 0, want: ""},
+		{loss: 49, want: ""},
+		{loss: 50, want: NAGInaccuracy},
+		{loss: 150, want: NAGMistake},
+		{loss: 300, want: NAGBlunder},
+	}
+	for _, tt := range tests {
+		if got := DefaultThresholds().NAG(tt.loss); got != tt.want {
+			t.Errorf("NAG(%d) = %q, want %q", tt.loss, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Package enginetest provides a fake UCI engine for tests, run by re-executing the test binary
+package enginetest
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/notnil/chess"
+	"io"
+	"os"
+	"strings"
+)
+
+// engineArg is the argument the test binary is started with to act as an engine
+const engineArg = "-fake-uci-engine"
+
+// Main runs the fake engine and exits if the test binary was started as an engine,
+// it must be called from TestMain before the tests are run
+func Main() {
+	for _, arg := range os.Args[1:] {
+		if arg == engineArg {
+			Run(os.Stdin, os.Stdout)
+			os.Exit(0)
+		}
+	}
+}
+
+// Command returns the path and arguments starting the current test binary as a fake engine
+func Command() (string, []string) {
+	return os.Args[0], []string{engineArg}
+}
+
+var pieceValues = map[chess.PieceType]int{
+	chess.Pawn:   100,
+	chess.Knight: 300,
+	chess.Bishop: 300,
+	chess.Rook:   500,
+	chess.Queen:  900,
+}
+
+// Run reads UCI commands from r and writes answers to w until quit is received.
+// Positions are searched one ply deep: the best move is the one leaving the side to move with the best material balance,
+// which is the score sent in centipawns.
+func Run(r io.Reader, w io.Writer) {
+	pos := chess.StartingPosition()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "uci":
+			fmt.Fprintln(w, "id name fake")
+			fmt.Fprintln(w, "uciok")
+		case "isready":
+			fmt.Fprintln(w, "readyok")
+		case "position":
+			if len(fields) > 2 && fields[1] == "fen" {
+				p := &chess.Position{}
+				if err := p.UnmarshalText([]byte(strings.Join(fields[2:], " "))); err == nil {
+					pos = p
+				}
+			} else {
+				pos = chess.StartingPosition()
+			}
+		case "go":
+			search(w, pos)
+		case "quit":
+			return
+		}
+	}
+}
+
+func search(w io.Writer, pos *chess.Position) {
+	var best *chess.Move
+	bestScore := 0
+	for _, m := range pos.ValidMoves() {
+		score := material(pos.Update(m), pos.Turn())
+		if best == nil || score > bestScore {
+			best, bestScore = m, score
+		}
+	}
+	if best == nil {
+		fmt.Fprintln(w, "info depth 0 score mate 0")
+		fmt.Fprintln(w, "bestmove (none)")
+		return
+	}
+	uci := chess.UCINotation{}.Encode(pos, best)
+	fmt.Fprintf(w, "info depth 1 score cp %d pv %s\n", bestScore, uci)
+	fmt.Fprintf(w, "bestmove %s\n", uci)
+}
+
+// material returns the material balance of color
+func material(pos *chess.Position, color chess.Color) int {
+	balance := 0
+	for _, piece := range pos.Board().SquareMap() {
+		if piece.Color() == color {
+			balance += pieceValues[piece.Type()]
+		} else {
+			balance -= pieceValues[piece.Type()]
+		}
+	}
+	return balance
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// MateScore is the centipawn value given to mates, minus the number of moves to mate
+const MateScore = 10000
+
+var evalRegexp = regexp.MustCompile(`\[%eval\s+(#?-?\d+(?:\.\d+)?)[^\]]*\]`)
+
+// Score is a position's evaluation
+type Score struct {
+	// CP is the evaluation in centipawns, used when Mate is 0
+	CP int
+	// Mate is the number of moves to mate, negative when getting mated
+	Mate int
+}
+
+// Negate returns the score from the other side's point of view
+func (s Score) Negate() Score {
+	return Score{CP: -s.CP, Mate: -s.Mate}
+}
+
+// Centipawns returns the score in centipawns, mates being worth MateScore minus the number of moves to mate
+func (s Score) Centipawns() int {
+	switch {
+	case s.Mate > 0:
+		return MateScore - s.Mate
+	case s.Mate < 0:
+		return -MateScore - s.Mate
+	}
+	return s.CP
+}
+
+// String formats the score as in [%eval] comments: pawns with two decimals or #N for mates
+func (s Score) String() string {
+	if s.Mate != 0 {
+		return fmt.Sprintf("#%d", s.Mate)
+	}
+	return strconv.FormatFloat(float64(s.CP)/100, 'f', 2, 64)
+}
+
+// ParseScore parses a score formatted as in [%eval] comments
+func ParseScore(s string) (Score, error) {
+	if len(s) > 1 && s[0] == '#' {
+		mate, err := strconv.Atoi(s[1:])
+		if err != nil || mate == 0 {
+			return Score{}, fmt.Errorf("invalid mate score %q", s)
+		}
+		return Score{Mate: mate}, nil
+	}
+	pawns, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Score{}, fmt.Errorf("invalid score %q", s)
+	}
+	return Score{CP: int(pawns*100 + 0.5*sign(pawns))}, nil
+}
+
+func sign(f float64) float64 {
+	if f < 0 {
+		return -1
+	}
+	return 1
+}
+
+// CommentScore returns the score of an [%eval] comment, ok is false if there is none
+func CommentScore(comment string) (Score, bool) {
+	match := evalRegexp.FindStringSubmatch(comment)
+	if match == nil {
+		return Score{}, false
+	}
+	s, err := ParseScore(match[1])
+	return s, err == nil
+}
+
+// Eval is the result of a position's analysis
+type Eval struct {
+	Depth int
+	// Score is given from the side to move's point of view by engines, Pool returns it from white's point of view
+	Score Score
+	// BestMove is the best move in UCI notation, empty for terminal positions
+	BestMove string
+	PV       []string
+	// Terminal is true for checkmates and stalemates, which are not analyzed by the engine
+	Terminal bool
+
+	hasScore bool
+}
@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"github.com/notnil/chess"
+	"sync"
+)
+
+// Pool is a set of engine processes analyzing positions concurrently
+type Pool struct {
+	engines []*Engine
+	idle    chan *Engine
+}
+
+// NewPool starts workers engine processes (at least one) configured with cfg
+func NewPool(cfg Config, workers int) (*Pool, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &Pool{idle: make(chan *Engine, workers)}
+	for i := 0; i < workers; i++ {
+		e, err := Start(cfg)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.engines = append(p.engines, e)
+		p.idle <- e
+	}
+	return p, nil
+}
+
+// Analyze evaluates all positions concurrently, evaluations are returned in order and from white's point of view.
+// Checkmates and stalemates are evaluated without the engine.
+func (p *Pool) Analyze(positions []*chess.Position) ([]Eval, error) {
+	evals := make([]Eval, len(positions))
+	errs := make([]error, len(positions))
+	wg := sync.WaitGroup{}
+	for i, pos := range positions {
+		if eval, ok := terminalEval(pos); ok {
+			evals[i] = eval
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, pos *chess.Position) {
+			defer wg.Done()
+			e := <-p.idle
+			defer func() { p.idle <- e }()
+
+			eval, err := e.Analyze(pos.String())
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			if pos.Turn() == chess.Black {
+				eval.Score = eval.Score.Negate()
+			}
+			evals[i] = eval
+		}(i, pos)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return evals, nil
+}
+
+// AnalyzeGame replays the game and evaluates the starting position and the position after each move
+func (p *Pool) AnalyzeGame(g *pgn.Game) ([]Eval, error) {
+	positions, err := g.Positions()
+	if err != nil {
+		return nil, fmt.Errorf("unable to replay game, err=%v", err)
+	}
+	return p.Analyze(positions)
+}
+
+// terminalEval evaluates checkmates and stalemates from white's point of view
+func terminalEval(pos *chess.Position) (Eval, bool) {
+	switch pos.Status() {
+	case chess.Checkmate:
+		score := Score{CP: MateScore}
+		if pos.Turn() == chess.White {
+			score.CP = -MateScore
+		}
+		return Eval{Score: score, Terminal: true}, true
+	case chess.Stalemate:
+		return Eval{Terminal: true}, true
+	}
+	return Eval{}, false
+}
+
+// Close quits all the engines
+func (p *Pool) Close() error {
+	var err error
+	for _, e := range p.engines {
+		if e2 := e.Close(); e2 != nil && err == nil {
+			err = e2
+		}
+	}
+	return err
+}
@@ -0,0 +1,203 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDepth is the search depth used when no limit is given
+const DefaultDepth = 15
+
+// Config configures the UCI engine processes
+type Config struct {
+	// Path is the path of the engine binary
+	Path string
+	// Args are the arguments given to the engine binary
+	Args []string
+	// Options are set with setoption before the analysis starts (e.g. Hash=256)
+	Options map[string]string
+	// Depth is the search depth of each position
+	Depth int
+	// MoveTime is the search time of each position, DefaultDepth is used if both Depth and MoveTime are zero
+	MoveTime time.Duration
+}
+
+// goCommand returns the UCI go command searching positions according to the configured limits
+func (c Config) goCommand() string {
+	cmd := "go"
+	if c.Depth > 0 {
+		cmd += fmt.Sprintf(" depth %d", c.Depth)
+	}
+	if c.MoveTime > 0 {
+		cmd += fmt.Sprintf(" movetime %d", c.MoveTime.Milliseconds())
+	}
+	if cmd == "go" {
+		cmd += fmt.Sprintf(" depth %d", DefaultDepth)
+	}
+	return cmd
+}
+
+// Engine is a running UCI engine process
+type Engine struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+	goCmd  string
+}
+
+// Start starts an engine process and initializes it: uci handshake and options
+func Start(cfg Config) (*Engine, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("no engine path configured")
+	}
+	cmd := exec.Command(cfg.Path, cfg.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("unable to start engine %s, err=%v", cfg.Path, err)
+	}
+
+	e := &Engine{cmd: cmd, stdin: stdin, stdout: bufio.NewScanner(stdout), goCmd: cfg.goCommand()}
+	if err := e.init(cfg.Options); err != nil {
+		e.Close()
+		return nil, fmt.Errorf("unable to initialize engine %s, err=%v", cfg.Path, err)
+	}
+	return e, nil
+}
+
+func (e *Engine) init(options map[string]string) error {
+	if err := e.send("uci"); err != nil {
+		return err
+	}
+	if _, err := e.readUntil("uciok"); err != nil {
+		return err
+	}
+	for name, value := range options {
+		if err := e.send(fmt.Sprintf("setoption name %s value %s", name, value)); err != nil {
+			return err
+		}
+	}
+	return e.ready()
+}
+
+// ready waits for the engine to be ready to receive commands
+func (e *Engine) ready() error {
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+	_, err := e.readUntil("readyok")
+	return err
+}
+
+func (e *Engine) send(cmd string) error {
+	_, err := io.WriteString(e.stdin, cmd+"\n")
+	return err
+}
+
+// readUntil reads lines until one starts with the given token and returns all lines read, that one included
+func (e *Engine) readUntil(token string) ([]string, error) {
+	var lines []string
+	for e.stdout.Scan() {
+		line := strings.TrimSpace(e.stdout.Text())
+		lines = append(lines, line)
+		if line == token || strings.HasPrefix(line, token+" ") {
+			return lines, nil
+		}
+	}
+	if err := e.stdout.Err(); err != nil {
+		return lines, err
+	}
+	return lines, fmt.Errorf("engine exited before sending %q", token)
+}
+
+// Analyze searches the position given as FEN and returns its evaluation from the side to move's point of view
+func (e *Engine) Analyze(fen string) (Eval, error) {
+	if err := e.send("position fen " + fen); err != nil {
+		return Eval{}, err
+	}
+	if err := e.send(e.goCmd); err != nil {
+		return Eval{}, err
+	}
+	lines, err := e.readUntil("bestmove")
+	if err != nil {
+		return Eval{}, err
+	}
+
+	eval := Eval{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) > 1 && fields[0] == "bestmove":
+			eval.BestMove = fields[1]
+		case len(fields) > 0 && fields[0] == "info":
+			// Only the main line is kept when several lines are searched
+			if multipv := infoField(fields, "multipv"); multipv != "" && multipv != "1" {
+				continue
+			}
+			parseInfo(fields, &eval)
+		}
+	}
+	if !eval.hasScore {
+		return eval, fmt.Errorf("engine did not send any score for %s", fen)
+	}
+	return eval, nil
+}
+
+// infoField returns the value following name in an info line
+func infoField(fields []string, name string) string {
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == name {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+// parseInfo updates eval with the depth, score and principal variation of an info line
+func parseInfo(fields []string, eval *Eval) {
+	for i := 1; i < len(fields); i++ {
+		switch fields[i] {
+		case "depth":
+			if i+1 < len(fields) {
+				eval.Depth, _ = strconv.Atoi(fields[i+1])
+			}
+		case "score":
+			if i+2 >= len(fields) {
+				continue
+			}
+			v, err := strconv.Atoi(fields[i+2])
+			if err != nil {
+				continue
+			}
+			switch fields[i+1] {
+			case "cp":
+				eval.Score = Score{CP: v}
+				eval.hasScore = true
+			case "mate":
+				eval.Score = Score{Mate: v}
+				eval.hasScore = true
+			}
+		case "pv":
+			eval.PV = append([]string(nil), fields[i+1:]...)
+			return
+		}
+	}
+}
+
+// Close quits the engine and waits for its process to exit
+func (e *Engine) Close() error {
+	e.send("quit")
+	e.stdin.Close()
+	return e.cmd.Wait()
+}
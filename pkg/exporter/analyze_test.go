@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"bytes"
+	"github.com/nmaupu/chesscom_exporter/pkg/engine"
+	"github.com/nmaupu/chesscom_exporter/pkg/engine/enginetest"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	enginetest.Main()
+	os.Exit(m.Run())
+}
+
+func TestNewGameWriter_Engine(t *testing.T) {
+	path, args := enginetest.Command()
+	pool, err := engine.NewPool(engine.Config{Path: path, Args: args, Depth: 1}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	buf := bytes.Buffer{}
+	w, err := NewGameWriter(&buf, Options{
+		Format:     FormatPGN,
+		PGN:        pgn.NormalizeOptions{StripClocks: true},
+		Engine:     pool,
+		Thresholds: engine.DefaultThresholds(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blunder := sampleGame()
+	blunder.PGN = strings.Replace(samplePGN, "2. Nf3", "2. Ba6", 1)
+	variant := sampleGame()
+	variant.Rules = "chess960"
+
+	tests := []struct {
+		name string
+		game model.ChesscomGame
+		want string
+	}{
+		{name: "blunder", game: blunder, want: "1. e4 {[%eval 0.00]} 1... e5 {[%eval 0.00]} 2. Ba6 $4 {[%eval -3.00]} 0-1"},
+		{name: "variant", game: variant, want: "\n1. e4 e5 2. Nf3 0-1"},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		if err := w.Write(tt.game); err != nil {
+			t.Fatalf("%s: Write() error = %v", tt.name, err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("%s: output does not contain %q:\n%s", tt.name, tt.want, buf.String())
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,112 @@
+package exporter
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Compression is the compression applied to output files
+type Compression string
+
+const (
+	CompressionNone Compression = "none"
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+	// CompressionZip packs several files and a manifest into a single zip archive
+	CompressionZip Compression = "zip"
+)
+
+// Compressions lists all the available compressions
+var Compressions = []Compression{CompressionNone, CompressionGzip, CompressionZstd, CompressionZip}
+
+// ParseCompression returns the Compression corresponding to the given name
+func ParseCompression(name string) (Compression, error) {
+	if name == "" {
+		return CompressionNone, nil
+	}
+	for _, c := range Compressions {
+		if strings.EqualFold(string(c), name) {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported compression %q", name)
+}
+
+// CompressionFromPath guesses the compression from a file's extension
+func CompressionFromPath(path string) Compression {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz":
+		return CompressionGzip
+	case ".zst":
+		return CompressionZstd
+	case ".zip":
+		return CompressionZip
+	}
+	return CompressionNone
+}
+
+// Extension returns the file extension suffix of this compression (with the dot)
+func (c Compression) Extension() string {
+	switch c {
+	case CompressionGzip:
+		return ".gz"
+	case CompressionZstd:
+		return ".zst"
+	case CompressionZip:
+		return ".zip"
+	}
+	return ""
+}
+
+// IsStream returns true if the compression applies to a single stream (as opposed to an archive of files)
+func (c Compression) IsStream() bool {
+	return c != CompressionZip
+}
+
+// NewCompressedWriter returns a writer compressing data to w.
+// Closing the returned writer flushes the compressed data but does not close w.
+func NewCompressedWriter(w io.Writer, c Compression) (io.WriteCloser, error) {
+	switch c {
+	case CompressionNone, "":
+		return nopWriteCloser{w}, nil
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("compression %q cannot be applied to a single stream", c)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// NewCompressedGameWriter returns a GameWriter writing games to w compressed with opts.Compression.
+// Zip bundles split games across files according to layout, layout is ignored otherwise.
+// Closing the returned GameWriter does not close w.
+func NewCompressedGameWriter(w io.Writer, layout LayoutOptions, opts Options) (GameWriter, error) {
+	switch opts.Compression {
+	case CompressionNone, "":
+		return NewGameWriter(w, opts)
+	case CompressionZip:
+		return NewZipBundleWriter(w, layout, opts)
+	}
+
+	cw, err := NewCompressedWriter(w, opts.Compression)
+	if err != nil {
+		return nil, err
+	}
+	gw, err := NewGameWriter(cw, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &fileGameWriter{GameWriter: gw, closers: []io.Closer{cw}}, nil
+}
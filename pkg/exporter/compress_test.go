@@ -0,0 +1,141 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreate_Compression(t *testing.T) {
+	tests := []struct {
+		name        string
+		compression Compression
+		reader      func(r io.Reader) (io.Reader, error)
+	}{
+		{
+			name:        "gzip",
+			compression: CompressionGzip,
+			reader:      func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		},
+		{
+			name:        "zstd",
+			compression: CompressionZstd,
+			reader:      func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "games.pgn"+tt.compression.Extension())
+			w, err := Create(path, Options{Format: FormatFromPath(path), Compression: CompressionFromPath(path)})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if err := w.Write(sampleGame()); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			r, err := tt.reader(f)
+			if err != nil {
+				t.Fatalf("unable to decompress, err=%v", err)
+			}
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatalf("unable to decompress, err=%v", err)
+			}
+			if string(data) != samplePGN+"\n" {
+				t.Errorf("unexpected content %q", data)
+			}
+		})
+	}
+}
+
+func TestFromPath(t *testing.T) {
+	tests := []struct {
+		path        string
+		format      Format
+		compression Compression
+	}{
+		{path: "games.pgn", format: FormatPGN, compression: CompressionNone},
+		{path: "games.pgn.gz", format: FormatPGN, compression: CompressionGzip},
+		{path: "games.ndjson.zst", format: FormatNDJSON, compression: CompressionZstd},
+		{path: "games.csv.zip", format: FormatCSV, compression: CompressionZip},
+		{path: "games.db", format: FormatSQLite, compression: CompressionNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := FormatFromPath(tt.path); got != tt.format {
+				t.Errorf("FormatFromPath() = %v, want %v", got, tt.format)
+			}
+			if got := CompressionFromPath(tt.path); got != tt.compression {
+				t.Errorf("CompressionFromPath() = %v, want %v", got, tt.compression)
+			}
+		})
+	}
+}
+
+func TestZipBundleWriter(t *testing.T) {
+	buf := bytes.Buffer{}
+	w, err := NewZipBundleWriter(&buf, LayoutOptions{Layout: LayoutMonth}, Options{Format: FormatPGN, Username: "erik"})
+	if err != nil {
+		t.Fatalf("NewZipBundleWriter() error = %v", err)
+	}
+	for _, end := range []time.Time{
+		time.Date(2021, time.July, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, time.July, 5, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, time.August, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		game := sampleGame()
+		game.EndTime = end.Unix()
+		if err := w.Write(game); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("invalid zip archive, err=%v", err)
+	}
+	var names []string
+	var manifest Manifest
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if f.Name != ManifestName {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+			t.Fatalf("invalid manifest, err=%v", err)
+		}
+		r.Close()
+	}
+
+	if got := strings.Join(names, ","); got != "erik/2021/2021-07.pgn,erik/2021/2021-08.pgn,manifest.json" {
+		t.Errorf("unexpected archive content %s", got)
+	}
+	if manifest.Username != "erik" || len(manifest.Files) != 2 || manifest.Files[0].Games != 2 {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+}
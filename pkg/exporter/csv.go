@@ -0,0 +1,118 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type csvColumn func(game model.ChesscomGame, r *Record) string
+
+// csvColumns maps each available CSV column to its value extractor
+var csvColumns = map[string]csvColumn{
+	"date":              func(_ model.ChesscomGame, r *Record) string { return r.EndTime.Format("2006-01-02") },
+	"time":              func(_ model.ChesscomGame, r *Record) string { return r.EndTime.Format("15:04:05") },
+	"time_class":        func(_ model.ChesscomGame, r *Record) string { return r.TimeClass },
+	"time_control":      func(_ model.ChesscomGame, r *Record) string { return r.TimeControl },
+	"rated":             func(_ model.ChesscomGame, r *Record) string { return strconv.FormatBool(r.Rated) },
+	"player":            func(_ model.ChesscomGame, r *Record) string { return r.Player },
+	"color":             func(_ model.ChesscomGame, r *Record) string { return r.Color },
+	"opponent":          func(_ model.ChesscomGame, r *Record) string { return r.Opponent },
+	"rating":            func(_ model.ChesscomGame, r *Record) string { return strconv.Itoa(r.Rating) },
+	"opponent_rating":   func(_ model.ChesscomGame, r *Record) string { return strconv.Itoa(r.OpponentRating) },
+	"result":            func(_ model.ChesscomGame, r *Record) string { return r.Result },
+	"termination":       func(_ model.ChesscomGame, r *Record) string { return r.Termination },
+	"eco":               func(_ model.ChesscomGame, r *Record) string { return r.ECO },
+	"opening":           func(_ model.ChesscomGame, r *Record) string { return r.Opening },
+	"opening_family":    func(_ model.ChesscomGame, r *Record) string { return r.OpeningFamily },
+	"chesscom_eco":      func(_ model.ChesscomGame, r *Record) string { return r.ChesscomECO },
+	"chesscom_opening":  func(_ model.ChesscomGame, r *Record) string { return r.ChesscomOpening },
+	"white_accuracy":    func(g model.ChesscomGame, _ *Record) string { return formatFloat(g.Accuracies.White) },
+	"black_accuracy":    func(g model.ChesscomGame, _ *Record) string { return formatFloat(g.Accuracies.Black) },
+	"accuracy":          func(_ model.ChesscomGame, r *Record) string { return formatFloat(r.Accuracy) },
+	"opponent_accuracy": func(_ model.ChesscomGame, r *Record) string { return formatFloat(r.OpponentAccuracy) },
+	"moves":             func(_ model.ChesscomGame, r *Record) string { return strconv.Itoa((len(r.Moves) + 1) / 2) },
+	"url":               func(_ model.ChesscomGame, r *Record) string { return r.URL },
+}
+
+// DefaultCSVColumns is the list of columns written when none are configured
+var DefaultCSVColumns = []string{
+	"date", "time_class", "color", "opponent", "rating", "opponent_rating", "result", "termination",
+	"eco", "opening", "white_accuracy", "black_accuracy", "moves", "url",
+}
+
+// ParseCSVColumns parses a comma separated list of columns and checks they all exist
+func ParseCSVColumns(s string) ([]string, error) {
+	var columns []string
+	for _, c := range strings.Split(s, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if _, ok := csvColumns[c]; !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", c)
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// csvWriter writes one row per game
+type csvWriter struct {
+	w       *csv.Writer
+	opts    Options
+	columns []string
+	header  bool
+}
+
+func newCSVWriter(w io.Writer, opts Options) (*csvWriter, error) {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+	for _, c := range columns {
+		if _, ok := csvColumns[c]; !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", c)
+		}
+	}
+	return &csvWriter{w: csv.NewWriter(w), opts: opts, columns: columns}, nil
+}
+
+func (c *csvWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	return c.w.Write(c.columns)
+}
+
+func (c *csvWriter) Write(game model.ChesscomGame) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	r, err := NewRecord(game, c.opts.Username)
+	if err != nil {
+		return err
+	}
+	row := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		row[i] = csvColumns[col](game, r)
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
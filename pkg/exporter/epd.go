@@ -0,0 +1,159 @@
+package exporter
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"github.com/notnil/chess"
+	"io"
+	"path"
+	"strings"
+)
+
+// PositionPoint is a point of a game at which a position is exported by the EPD and FEN formats
+type PositionPoint string
+
+const (
+	// PositionEveryMove exports the position after each move
+	PositionEveryMove PositionPoint = "every-move"
+	// PositionOpening exports the position reached after Options.OpeningPly half-moves
+	PositionOpening PositionPoint = "opening"
+	// PositionBlunder exports the position before each move annotated as a blunder ($4 or ??)
+	PositionBlunder PositionPoint = "blunder"
+	// PositionFinal exports the final position
+	PositionFinal PositionPoint = "final"
+)
+
+// PositionPoints lists all the available position points
+var PositionPoints = []PositionPoint{PositionEveryMove, PositionOpening, PositionBlunder, PositionFinal}
+
+// DefaultOpeningPly is the number of half-moves after which the opening is considered over
+const DefaultOpeningPly = 20
+
+// ParsePositionPoints parses a comma separated list of position points, the final position is used if empty
+func ParsePositionPoints(s string) ([]PositionPoint, error) {
+	var points []PositionPoint
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, p := range PositionPoints {
+			if strings.EqualFold(string(p), name) {
+				points = append(points, p)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown position point %q, expected one of %v", name, PositionPoints)
+		}
+	}
+	if len(points) == 0 {
+		points = []PositionPoint{PositionFinal}
+	}
+	return points, nil
+}
+
+// positionWriter writes positions of each game, one per line, either as EPD with opcodes or as plain FEN
+type positionWriter struct {
+	w    io.Writer
+	opts Options
+	fen  bool
+}
+
+func newPositionWriter(w io.Writer, opts Options, fen bool) (*positionWriter, error) {
+	if len(opts.Positions) == 0 {
+		opts.Positions = []PositionPoint{PositionFinal}
+	}
+	if opts.OpeningPly <= 0 {
+		opts.OpeningPly = DefaultOpeningPly
+	}
+	return &positionWriter{w: w, opts: opts, fen: fen}, nil
+}
+
+func (p *positionWriter) Write(game model.ChesscomGame) error {
+	if game.Rules != "" && game.Rules != "chess" {
+		return nil // variants cannot be replayed
+	}
+	g, err := pgn.Parse(game.PGN)
+	if err != nil {
+		return fmt.Errorf("unable to parse PGN, err=%v", err)
+	}
+	positions, err := g.Positions()
+	if err != nil {
+		return err
+	}
+
+	for _, ply := range p.plies(g, len(positions)-1) {
+		line := positions[ply].String()
+		if !p.fen {
+			line = epd(game, positions[ply], ply, g)
+		}
+		if _, err := io.WriteString(p.w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// plies returns the indexes of the positions to export, in order and without duplicates
+func (p *positionWriter) plies(g *pgn.Game, last int) []int {
+	selected := make([]bool, last+1)
+	for _, point := range p.opts.Positions {
+		switch point {
+		case PositionEveryMove:
+			for i := 1; i <= last; i++ {
+				selected[i] = true
+			}
+		case PositionOpening:
+			if p.opts.OpeningPly <= last {
+				selected[p.opts.OpeningPly] = true
+			}
+		case PositionBlunder:
+			for i, m := range g.Moves {
+				if m.IsBlunder() {
+					selected[i] = true
+				}
+			}
+		case PositionFinal:
+			selected[last] = true
+		}
+	}
+
+	var plies []int
+	for i, ok := range selected {
+		if ok {
+			plies = append(plies, i)
+		}
+	}
+	return plies
+}
+
+// epd returns the EPD line of the position reached after ply half-moves.
+// The id opcode identifies the position, c0 gives the source game URL, the move
+// played from a blunder position is given by the am (avoid move) opcode.
+func epd(game model.ChesscomGame, pos *chess.Position, ply int, g *pgn.Game) string {
+	fields := strings.Fields(pos.String())
+	sb := strings.Builder{}
+	sb.WriteString(strings.Join(fields[:4], " "))
+	if ply < len(g.Moves) && g.Moves[ply].IsBlunder() {
+		fmt.Fprintf(&sb, " am %s;", strings.TrimRight(g.Moves[ply].SAN, "?!"))
+	}
+	fmt.Fprintf(&sb, " hmvc %s; fmvn %s;", fields[4], fields[5])
+	fmt.Fprintf(&sb, " id %s;", epdString(fmt.Sprintf("%s.%d", path.Base(game.URL), ply)))
+	if game.URL != "" {
+		fmt.Fprintf(&sb, " c0 %s;", epdString(game.URL))
+	}
+	return sb.String()
+}
+
+// epdString quotes an EPD string operand, which cannot contain double quotes
+func epdString(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
+
+func (p *positionWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,121 @@
+package exporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPositionWriter(t *testing.T) {
+	blunder := sampleGame()
+	blunder.PGN = strings.Replace(samplePGN, "2. Nf3", "2. Qh5 $4", 1)
+
+	tests := []struct {
+		name   string
+		format Format
+		points []PositionPoint
+		ply    int
+		game   func() string
+		want   []string
+	}{
+		{
+			name:   "final epd",
+			format: FormatEPD,
+			want: []string{
+				`rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - hmvc 1; fmvn 2; id "1.3"; c0 "https://www.chess.com/game/live/1";`,
+			},
+		},
+		{
+			name:   "final fen",
+			format: FormatFEN,
+			want:   []string{"rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"},
+		},
+		{
+			name:   "every move and final without duplicates",
+			format: FormatFEN,
+			points: []PositionPoint{PositionEveryMove, PositionFinal},
+			want: []string{
+				"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+				"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2",
+				"rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2",
+			},
+		},
+		{
+			name:   "opening",
+			format: FormatFEN,
+			points: []PositionPoint{PositionOpening},
+			ply:    2,
+			want:   []string{"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"},
+		},
+		{
+			name:   "opening after the end of the game",
+			format: FormatFEN,
+			points: []PositionPoint{PositionOpening},
+			ply:    40,
+		},
+		{
+			name:   "blunder",
+			format: FormatEPD,
+			points: []PositionPoint{PositionBlunder},
+			game:   func() string { return blunder.PGN },
+			want: []string{
+				`rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 am Qh5; hmvc 0; fmvn 2; id "1.2"; c0 "https://www.chess.com/game/live/1";`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := sampleGame()
+			if tt.game != nil {
+				game.PGN = tt.game()
+			}
+			buf := bytes.Buffer{}
+			w, err := NewGameWriter(&buf, Options{Format: tt.format, Positions: tt.points, OpeningPly: tt.ply})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Write(game); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			if s := strings.TrimSpace(buf.String()); s != "" {
+				got = strings.Split(s, "\n")
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestParsePositionPoints(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    []PositionPoint
+		wantErr bool
+	}{
+		{s: "", want: []PositionPoint{PositionFinal}},
+		{s: "opening, Blunder", want: []PositionPoint{PositionOpening, PositionBlunder}},
+		{s: "middlegame", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePositionPoints(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePositionPoints(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParsePositionPoints(%q) = %v, want %v", tt.s, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ParsePositionPoints(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		}
+	}
+}
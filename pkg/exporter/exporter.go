@@ -0,0 +1,300 @@
+package exporter
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/engine"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is an output format supported by the exporter
+type Format string
+
+const (
+	FormatPGN     Format = "pgn"
+	FormatJSON    Format = "json"
+	FormatNDJSON  Format = "ndjson"
+	FormatCSV     Format = "csv"
+	FormatSQLite  Format = "sqlite"
+	FormatParquet Format = "parquet"
+	FormatEPD     Format = "epd"
+	FormatFEN     Format = "fen"
+)
+
+// Formats lists all the available output formats
+var Formats = []Format{FormatPGN, FormatJSON, FormatNDJSON, FormatCSV, FormatSQLite, FormatParquet, FormatEPD, FormatFEN}
+
+// ParseFormat returns the Format corresponding to the given name
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(string(f), name) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported format %q", name)
+}
+
+// FormatFromPath guesses the format from a file's extension, PGN is returned if unknown.
+// Compression extensions (e.g. games.pgn.gz) are not taken into account.
+func FormatFromPath(path string) Format {
+	if c := CompressionFromPath(path); c != CompressionNone {
+		path = strings.TrimSuffix(path, filepath.Ext(path))
+	}
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	switch ext {
+	case "db", "sqlite", "sqlite3":
+		return FormatSQLite
+	case "jsonl":
+		return FormatNDJSON
+	}
+	if f, err := ParseFormat(ext); err == nil {
+		return f
+	}
+	return FormatPGN
+}
+
+// Extension returns the file extension to use for this format (without the dot)
+func (f Format) Extension() string {
+	if f == FormatSQLite {
+		return "db"
+	}
+	return string(f)
+}
+
+// IsStream returns true if this format can be written to any io.Writer,
+// false if it needs a file path (e.g. a database)
+func (f Format) IsStream() bool {
+	return f != FormatSQLite
+}
+
+// IsBinary returns true if this format is not human readable text
+func (f Format) IsBinary() bool {
+	return f == FormatSQLite || f == FormatParquet
+}
+
+// SupportsNormalized returns true if this format can write either raw games or normalized records
+func (f Format) SupportsNormalized() bool {
+	return f == FormatJSON || f == FormatNDJSON
+}
+
+// Options configures how games are written
+type Options struct {
+	Format Format
+	// Normalized writes enriched records instead of raw chess.com games (JSON formats only)
+	Normalized bool
+	// Username is the player used as the perspective of normalized records and CSV rows
+	Username string
+	// Columns is the list of columns written by the CSV format, DefaultCSVColumns if empty
+	Columns []string
+	// Compression is applied to each file written, no compression if empty
+	Compression Compression
+	// PGN rewrites games' PGN before they are written
+	PGN pgn.NormalizeOptions
+	// Manifest, if not nil, records the files written by split writers and zip bundles
+	Manifest *Manifest
+	// MovesPath is the path of a second file receiving one row per move (parquet only, ignored if empty)
+	MovesPath string
+	// Positions are the points of each game at which positions are written (EPD and FEN only), final if empty
+	Positions []PositionPoint
+	// OpeningPly is the number of half-moves after which the opening is over, DefaultOpeningPly if <= 0
+	OpeningPly int
+	// Engine, if not nil, analyzes games and annotates their PGN with [%eval] comments before they are rewritten
+	// and written, variants are left unanalyzed
+	Engine *engine.Pool
+	// Thresholds are the centipawn losses from which analyzed moves get inaccuracy, mistake and blunder NAGs
+	Thresholds engine.Thresholds
+}
+
+// GameWriter writes chess.com games to an output, one game at a time.
+// Close must be called once all games have been written, it does not close the underlying writer.
+type GameWriter interface {
+	Write(game model.ChesscomGame) error
+	Close() error
+}
+
+// NewGameWriter returns a GameWriter writing games to w using the given options
+func NewGameWriter(w io.Writer, opts Options) (GameWriter, error) {
+	gw, err := newGameWriter(w, opts)
+	if err != nil {
+		return nil, err
+	}
+	return rewrite(gw, opts), nil
+}
+
+func newGameWriter(w io.Writer, opts Options) (GameWriter, error) {
+	switch opts.Format {
+	case FormatPGN, "":
+		return &pgnWriter{w: w}, nil
+	case FormatJSON:
+		return &jsonWriter{w: w, opts: opts}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{w: w, opts: opts}, nil
+	case FormatCSV:
+		return newCSVWriter(w, opts)
+	case FormatParquet:
+		return NewParquetWriter(w, nil)
+	case FormatEPD:
+		return newPositionWriter(w, opts, false)
+	case FormatFEN:
+		return newPositionWriter(w, opts, true)
+	case FormatSQLite:
+		return nil, fmt.Errorf("format %q needs a file path, use Create instead", opts.Format)
+	}
+	return nil, fmt.Errorf("unsupported format %q", opts.Format)
+}
+
+// Create returns a GameWriter writing to the file at path, the file is created or truncated
+// for stream formats and the database is created or updated otherwise.
+func Create(path string, opts Options) (GameWriter, error) {
+	if !opts.Format.IsStream() {
+		if opts.Compression != CompressionNone && opts.Compression != "" {
+			return nil, fmt.Errorf("format %q cannot be compressed", opts.Format)
+		}
+		gw, err := NewSQLiteWriter(path)
+		if err != nil {
+			return nil, err
+		}
+		return rewrite(gw, opts), nil
+	}
+
+	fw := &fileGameWriter{}
+	out, err := fw.create(path, opts.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Format == FormatParquet && opts.MovesPath != "" {
+		moves, err := fw.create(opts.MovesPath, opts.Compression)
+		if err != nil {
+			fw.closeAll()
+			return nil, err
+		}
+		var gw GameWriter
+		if gw, err = NewParquetWriter(out, moves); err == nil {
+			fw.GameWriter = rewrite(gw, opts)
+		}
+	} else {
+		fw.GameWriter, err = NewGameWriter(out, opts)
+	}
+	if err != nil {
+		fw.closeAll()
+		return nil, err
+	}
+	return fw, nil
+}
+
+// fileGameWriter closes the underlying files and compressors once the wrapped GameWriter is closed
+type fileGameWriter struct {
+	GameWriter
+	closers []io.Closer // closed in order
+}
+
+// create creates the file at path and returns a writer compressing data to it,
+// both are closed when the fileGameWriter is closed
+func (f *fileGameWriter) create(path string, c Compression) (io.Writer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	cw, err := NewCompressedWriter(file, c)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f.closers = append(f.closers, cw, file)
+	return cw, nil
+}
+
+func (f *fileGameWriter) Close() error {
+	if err := f.GameWriter.Close(); err != nil {
+		f.closeAll()
+		return err
+	}
+	return f.closeAll()
+}
+
+func (f *fileGameWriter) closeAll() error {
+	var err error
+	for _, c := range f.closers {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// value returns what has to be serialized for a game given the options
+func (o Options) value(game model.ChesscomGame) (interface{}, error) {
+	if !o.Normalized {
+		return game, nil
+	}
+	return NewRecord(game, o.Username)
+}
+
+// rewrite wraps w so that games are analyzed then have their PGN rewritten according to opts
+func rewrite(w GameWriter, opts Options) GameWriter {
+	return analyze(normalize(w, opts), opts)
+}
+
+// analyzingWriter annotates each game's PGN with engine evaluations before handing it to the wrapped GameWriter
+type analyzingWriter struct {
+	GameWriter
+	pool       *engine.Pool
+	thresholds engine.Thresholds
+}
+
+// analyze wraps w so that games are analyzed by opts.Engine, w is returned as is if there is no engine
+func analyze(w GameWriter, opts Options) GameWriter {
+	if opts.Engine == nil {
+		return w
+	}
+	return &analyzingWriter{GameWriter: w, pool: opts.Engine, thresholds: opts.Thresholds}
+}
+
+func (a *analyzingWriter) Write(game model.ChesscomGame) error {
+	if game.Rules != "" && game.Rules != "chess" {
+		return a.GameWriter.Write(game)
+	}
+	g, err := pgn.Parse(game.PGN)
+	if err != nil {
+		return fmt.Errorf("unable to parse PGN of game %s, err=%v", game.URL, err)
+	}
+	evals, err := a.pool.AnalyzeGame(g)
+	if err != nil {
+		return fmt.Errorf("unable to analyze game %s, err=%v", game.URL, err)
+	}
+	if err := engine.Annotate(g, evals, a.thresholds); err != nil {
+		return err
+	}
+	game.PGN = g.String()
+	return a.GameWriter.Write(game)
+}
+
+// normalizingWriter rewrites each game's PGN before handing it to the wrapped GameWriter
+type normalizingWriter struct {
+	GameWriter
+	opts pgn.NormalizeOptions
+}
+
+// normalize wraps w so that games' PGN are rewritten according to opts.PGN, w is returned as is if
+// there is nothing to rewrite
+func normalize(w GameWriter, opts Options) GameWriter {
+	if opts.PGN.IsZero() {
+		return w
+	}
+	return &normalizingWriter{GameWriter: w, opts: opts.PGN}
+}
+
+func (n *normalizingWriter) Write(game model.ChesscomGame) error {
+	g, err := pgn.Parse(game.PGN)
+	if err != nil {
+		return fmt.Errorf("unable to parse PGN of game %s, err=%v", game.URL, err)
+	}
+	g.Normalize(n.opts)
+	game.PGN = g.FormatWith(n.opts.FormatOptions())
+	return n.GameWriter.Write(game)
+}
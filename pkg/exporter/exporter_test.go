@@ -0,0 +1,218 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"strings"
+	"testing"
+)
+
+const samplePGN = `[Event "Live Chess"]
+[White "erik"]
+[Black "hikaru"]
+[Result "0-1"]
+[ECO "C50"]
+[ECOUrl "https://www.chess.com/openings/Italian-Game-Two-Knights-Defense"]
+[TimeControl "180"]
+[Termination "hikaru won by resignation"]
+
+1. e4 {[%clk 0:02:59.9]} 1... e5 {[%clk 0:02:59.1]} 2. Nf3 {[%clk 0:02:58]} 0-1
+`
+
+func sampleGame() model.ChesscomGame {
+	g := model.ChesscomGame{
+		URL:       "https://www.chess.com/game/live/1",
+		PGN:       samplePGN,
+		EndTime:   1625400000,
+		TimeClass: "blitz",
+		Rules:     "chess",
+		White:     model.ChesscomPlayerInfo{Username: "erik", Rating: 1500, Result: "resigned"},
+		Black:     model.ChesscomPlayerInfo{Username: "Hikaru", Rating: 3200, Result: "win"},
+	}
+	g.Accuracies.White = 80.5
+	g.Accuracies.Black = 98.1
+	return g
+}
+
+func TestNewRecord(t *testing.T) {
+	r, err := NewRecord(sampleGame(), "hikaru")
+	if err != nil {
+		t.Fatalf("NewRecord() error = %v", err)
+	}
+	if r.Color != model.ColorBlack || r.Player != "Hikaru" || r.Opponent != "erik" {
+		t.Errorf("wrong perspective: color=%s player=%s opponent=%s", r.Color, r.Player, r.Opponent)
+	}
+	if r.Result != model.OutcomeWin || r.OpponentCode != "resigned" {
+		t.Errorf("wrong result: %s/%s", r.Result, r.OpponentCode)
+	}
+	if r.Accuracy != 98.1 || r.OpponentAccuracy != 80.5 {
+		t.Errorf("wrong accuracies: %v/%v", r.Accuracy, r.OpponentAccuracy)
+	}
+	// The opening is classified from the moves, chess.com's tags are kept apart
+	if r.ECO != "C40" || r.Opening != "King's Knight Opening" || r.OpeningFamily != "King's Knight Opening" {
+		t.Errorf("wrong opening: %s %s", r.ECO, r.Opening)
+	}
+	if r.ChesscomECO != "C50" || r.ChesscomOpening != "Italian Game Two Knights Defense" {
+		t.Errorf("wrong chess.com opening: %s %s", r.ChesscomECO, r.ChesscomOpening)
+	}
+	if len(r.Moves) != 3 || r.TimeControl != "180" {
+		t.Errorf("wrong moves or time control: %v %s", r.Moves, r.TimeControl)
+	}
+}
+
+func TestNewGameWriter(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		games int
+		check func(t *testing.T, out string)
+	}{
+		{
+			name:  "pgn",
+			opts:  Options{Format: FormatPGN},
+			games: 2,
+			check: func(t *testing.T, out string) {
+				if strings.Count(out, "[Event ") != 2 {
+					t.Errorf("expected 2 games, got %s", out)
+				}
+			},
+		},
+		{
+			name:  "json empty",
+			opts:  Options{Format: FormatJSON},
+			games: 0,
+			check: func(t *testing.T, out string) {
+				if out != "[]\n" {
+					t.Errorf("expected empty array, got %q", out)
+				}
+			},
+		},
+		{
+			name:  "json raw",
+			opts:  Options{Format: FormatJSON},
+			games: 2,
+			check: func(t *testing.T, out string) {
+				var games []model.ChesscomGame
+				if err := json.Unmarshal([]byte(out), &games); err != nil {
+					t.Fatalf("invalid json: %v", err)
+				}
+				if len(games) != 2 || games[0].URL != sampleGame().URL {
+					t.Errorf("unexpected games %+v", games)
+				}
+			},
+		},
+		{
+			name:  "ndjson normalized",
+			opts:  Options{Format: FormatNDJSON, Normalized: true, Username: "erik"},
+			games: 2,
+			check: func(t *testing.T, out string) {
+				lines := strings.Split(strings.TrimSpace(out), "\n")
+				if len(lines) != 2 {
+					t.Fatalf("expected 2 lines, got %d", len(lines))
+				}
+				var r Record
+				if err := json.Unmarshal([]byte(lines[0]), &r); err != nil {
+					t.Fatalf("invalid json: %v", err)
+				}
+				if r.Player != "erik" || r.Result != model.OutcomeLoss {
+					t.Errorf("unexpected record %+v", r)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			w, err := NewGameWriter(&buf, tt.opts)
+			if err != nil {
+				t.Fatalf("NewGameWriter() error = %v", err)
+			}
+			for i := 0; i < tt.games; i++ {
+				if err := w.Write(sampleGame()); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			tt.check(t, buf.String())
+		})
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		want    string
+	}{
+		{
+			name: "default columns",
+			want: "date,time_class,color,opponent,rating,opponent_rating,result,termination,eco,opening,white_accuracy,black_accuracy,moves,url\n" +
+				"2021-07-04,blitz,white,Hikaru,1500,3200,loss,hikaru won by resignation,C40,King's Knight Opening,80.5,98.1,2,https://www.chess.com/game/live/1\n",
+		},
+		{
+			name:    "custom columns",
+			columns: []string{"player", "result", "accuracy"},
+			want:    "player,result,accuracy\nerik,loss,80.5\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			w, err := NewGameWriter(&buf, Options{Format: FormatCSV, Username: "erik", Columns: tt.columns})
+			if err != nil {
+				t.Fatalf("NewGameWriter() error = %v", err)
+			}
+			if err := w.Write(sampleGame()); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSVColumns(t *testing.T) {
+	cols, err := ParseCSVColumns("date, url,,result")
+	if err != nil || len(cols) != 3 {
+		t.Errorf("ParseCSVColumns() = %v, %v", cols, err)
+	}
+	if _, err := ParseCSVColumns("date,unknown"); err == nil {
+		t.Errorf("ParseCSVColumns() expected an error")
+	}
+}
+
+func TestNewGameWriter_NormalizedPGN(t *testing.T) {
+	buf := bytes.Buffer{}
+	w, err := NewGameWriter(&buf, Options{
+		Format: FormatPGN,
+		PGN: pgn.NormalizeOptions{
+			StripClocks: true,
+			DropTags:    []string{"ECOUrl", "Termination", "TimeControl", "ECO"},
+			AddTags:     []pgn.Tag{{Name: "Annotator", Value: "coach"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewGameWriter() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := w.Write(sampleGame()); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	game := "[Event \"Live Chess\"]\n[White \"erik\"]\n[Black \"hikaru\"]\n[Result \"0-1\"]\n[Annotator \"coach\"]\n\n1. e4 e5 2. Nf3 0-1\n\n"
+	if got := buf.String(); got != game+game {
+		t.Errorf("got %q, want %q", got, game+game)
+	}
+}
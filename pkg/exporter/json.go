@@ -0,0 +1,65 @@
+package exporter
+
+import (
+	"encoding/json"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"io"
+)
+
+// jsonWriter writes all games as a single JSON array
+type jsonWriter struct {
+	w     io.Writer
+	opts  Options
+	count int
+}
+
+func (j *jsonWriter) Write(game model.ChesscomGame) error {
+	v, err := j.opts.value(game)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "[\n"
+	}
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	if _, err := j.w.Write(data); err != nil {
+		return err
+	}
+	j.count++
+	return nil
+}
+
+func (j *jsonWriter) Close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+// ndjsonWriter writes one JSON object per line
+type ndjsonWriter struct {
+	w    io.Writer
+	opts Options
+}
+
+func (n *ndjsonWriter) Write(game model.ChesscomGame) error {
+	v, err := n.opts.value(game)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(n.w).Encode(v)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
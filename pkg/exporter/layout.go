@@ -0,0 +1,205 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Layout defines how exported games are split across files
+type Layout string
+
+const (
+	LayoutSingle    Layout = "single"
+	LayoutMonth     Layout = "month"
+	LayoutYear      Layout = "year"
+	LayoutTimeClass Layout = "time-class"
+	LayoutChunk     Layout = "chunk"
+)
+
+// Layouts lists all the available layouts
+var Layouts = []Layout{LayoutSingle, LayoutMonth, LayoutYear, LayoutTimeClass, LayoutChunk}
+
+// DefaultTemplates are the file name templates used for each layout when none is given.
+// Templates are relative to the output directory and use FileNameData as data.
+var DefaultTemplates = map[Layout]string{
+	LayoutSingle:    "chesscom-export-{{.User}}.{{.Ext}}",
+	LayoutMonth:     "{{.User}}/{{.Year}}/{{.Year}}-{{.Month}}.{{.Ext}}",
+	LayoutYear:      "{{.User}}/{{.Year}}.{{.Ext}}",
+	LayoutTimeClass: "{{.User}}/{{.TimeClass}}.{{.Ext}}",
+	LayoutChunk:     `{{.User}}/{{.User}}-{{printf "%03d" .Part}}.{{.Ext}}`,
+}
+
+// ParseLayout returns the Layout corresponding to the given name
+func ParseLayout(name string) (Layout, error) {
+	for _, l := range Layouts {
+		if strings.EqualFold(string(l), name) {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported layout %q", name)
+}
+
+// FileNameData is the data available to file name templates
+type FileNameData struct {
+	User      string
+	Year      string
+	Month     string
+	TimeClass string
+	Part      int
+	Ext       string
+}
+
+// LayoutOptions configures how games are split across files
+type LayoutOptions struct {
+	Layout Layout
+	// Dir is the directory where files are created
+	Dir string
+	// Template is the file name template, the layout's default template is used if empty
+	Template string
+	// MaxGames is the maximum number of games per file for LayoutChunk
+	MaxGames int
+}
+
+// splitWriter dispatches games to one GameWriter per file
+type splitWriter struct {
+	layout  LayoutOptions
+	opts    Options
+	tmpl    *template.Template
+	writers map[string]GameWriter
+	paths   []string // files in creation order
+	games   map[string]int
+	current string // last file written, closed when changing part for LayoutChunk
+	count   int
+}
+
+// NewSplitWriter returns a GameWriter writing games to several files according to the given layout.
+// Files are created lazily, only when a game has to be written to them.
+func NewSplitWriter(layout LayoutOptions, opts Options) (GameWriter, error) {
+	return newSplitWriter(layout, opts)
+}
+
+func newSplitWriter(layout LayoutOptions, opts Options) (*splitWriter, error) {
+	if !opts.Format.IsStream() {
+		return nil, fmt.Errorf("format %q cannot be split across files", opts.Format)
+	}
+	if opts.MovesPath != "" {
+		return nil, fmt.Errorf("a moves output cannot be used when splitting files")
+	}
+	if layout.Layout == LayoutChunk && layout.MaxGames <= 0 {
+		return nil, fmt.Errorf("a maximum number of games per file is needed for layout %q", LayoutChunk)
+	}
+
+	text := layout.Template
+	if text == "" {
+		var ok bool
+		if text, ok = DefaultTemplates[layout.Layout]; !ok {
+			return nil, fmt.Errorf("unsupported layout %q", layout.Layout)
+		}
+	}
+	tmpl, err := template.New("filename").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file name template, err=%v", err)
+	}
+
+	return &splitWriter{
+		layout:  layout,
+		opts:    opts,
+		tmpl:    tmpl,
+		writers: make(map[string]GameWriter),
+		games:   make(map[string]int),
+	}, nil
+}
+
+func (s *splitWriter) Write(game model.ChesscomGame) error {
+	path, err := s.path(game)
+	if err != nil {
+		return err
+	}
+
+	if s.layout.Layout == LayoutChunk && s.current != "" && s.current != path {
+		// A chunk never receives games anymore once full
+		if err := s.writers[s.current].Close(); err != nil {
+			return err
+		}
+		delete(s.writers, s.current)
+	}
+	s.current = path
+
+	w, ok := s.writers[path]
+	if !ok {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if w, err = Create(path, s.opts); err != nil {
+			return err
+		}
+		s.writers[path] = w
+		s.paths = append(s.paths, path)
+	}
+
+	s.count++
+	s.games[path]++
+	return w.Write(game)
+}
+
+// path returns the path of the file game has to be written to
+func (s *splitWriter) path(game model.ChesscomGame) (string, error) {
+	end := time.Unix(game.EndTime, 0).UTC()
+	timeClass := game.TimeClass
+	if timeClass == "" {
+		timeClass = "unknown"
+	}
+	part := 1
+	if s.layout.Layout == LayoutChunk {
+		part = s.count/s.layout.MaxGames + 1
+	}
+
+	buf := bytes.Buffer{}
+	err := s.tmpl.Execute(&buf, FileNameData{
+		User:      s.opts.Username,
+		Year:      end.Format("2006"),
+		Month:     end.Format("01"),
+		TimeClass: timeClass,
+		Part:      part,
+		Ext:       s.opts.Format.Extension() + s.opts.Compression.Extension(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to compute file name, err=%v", err)
+	}
+	return filepath.Join(s.layout.Dir, filepath.FromSlash(buf.String())), nil
+}
+
+func (s *splitWriter) Close() error {
+	var err error
+	for _, path := range s.paths {
+		w, ok := s.writers[path]
+		if !ok { // already closed
+			continue
+		}
+		if e := w.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	s.writers = nil
+	if err != nil || s.opts.Manifest == nil {
+		return err
+	}
+
+	s.opts.Manifest.Layout = s.layout.Layout
+	for _, path := range s.paths {
+		rel, err := filepath.Rel(s.layout.Dir, path)
+		if err != nil {
+			return err
+		}
+		if err := s.opts.Manifest.AddFile(s.layout.Dir, rel, s.games[path]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package exporter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitWriter(t *testing.T) {
+	july := time.Date(2021, time.July, 4, 10, 0, 0, 0, time.UTC).Unix()
+	august := time.Date(2021, time.August, 1, 10, 0, 0, 0, time.UTC).Unix()
+	january := time.Date(2022, time.January, 1, 10, 0, 0, 0, time.UTC).Unix()
+
+	tests := []struct {
+		name   string
+		layout LayoutOptions
+		want   map[string]int // file => number of games
+	}{
+		{
+			name:   "month",
+			layout: LayoutOptions{Layout: LayoutMonth},
+			want:   map[string]int{"erik/2021/2021-07.pgn": 2, "erik/2021/2021-08.pgn": 1, "erik/2022/2022-01.pgn": 1},
+		},
+		{
+			name:   "year",
+			layout: LayoutOptions{Layout: LayoutYear},
+			want:   map[string]int{"erik/2021.pgn": 3, "erik/2022.pgn": 1},
+		},
+		{
+			name:   "time class",
+			layout: LayoutOptions{Layout: LayoutTimeClass},
+			want:   map[string]int{"erik/blitz.pgn": 3, "erik/daily.pgn": 1},
+		},
+		{
+			name:   "chunk",
+			layout: LayoutOptions{Layout: LayoutChunk, MaxGames: 3},
+			want:   map[string]int{"erik/erik-001.pgn": 3, "erik/erik-002.pgn": 1},
+		},
+		{
+			name:   "custom template",
+			layout: LayoutOptions{Layout: LayoutMonth, Template: "{{.Year}}{{.Month}}-{{.TimeClass}}.{{.Ext}}"},
+			want:   map[string]int{"202107-blitz.pgn": 1, "202107-daily.pgn": 1, "202108-blitz.pgn": 1, "202201-blitz.pgn": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.layout.Dir = dir
+			w, err := NewSplitWriter(tt.layout, Options{Format: FormatPGN, Username: "erik"})
+			if err != nil {
+				t.Fatalf("NewSplitWriter() error = %v", err)
+			}
+			for i, end := range []int64{july, july, august, january} {
+				game := sampleGame()
+				game.EndTime = end
+				if i == 1 {
+					game.TimeClass = "daily"
+				}
+				if err := w.Write(game); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			got := map[string]int{}
+			err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				data, err := ioutil.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				rel, _ := filepath.Rel(dir, path)
+				got[filepath.ToSlash(rel)] = strings.Count(string(data), "[Event ")
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				files := make([]string, 0, len(got))
+				for f := range got {
+					files = append(files, f)
+				}
+				sort.Strings(files)
+				t.Errorf("got files %v (%v), want %v", files, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSplitWriter_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout LayoutOptions
+		opts   Options
+	}{
+		{name: "database", layout: LayoutOptions{Layout: LayoutMonth}, opts: Options{Format: FormatSQLite}},
+		{name: "chunk without size", layout: LayoutOptions{Layout: LayoutChunk}, opts: Options{Format: FormatPGN}},
+		{name: "invalid template", layout: LayoutOptions{Layout: LayoutMonth, Template: "{{.Year"}, opts: Options{Format: FormatPGN}},
+		{name: "unknown layout", layout: LayoutOptions{Layout: "weekly"}, opts: Options{Format: FormatPGN}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSplitWriter(tt.layout, tt.opts); err == nil {
+				t.Errorf("NewSplitWriter() expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package exporter
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ManifestName is the name of the manifest file written along with exported files
+const ManifestName = "manifest.json"
+
+// ManifestSuffix is appended to an exported file's name to get the name of its manifest
+const ManifestSuffix = ".manifest.json"
+
+// Tool describes the program producing exports, it is set by the main package at startup
+var Tool ToolInfo
+
+// ToolInfo describes the program and version producing an export
+type ToolInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	BuildDate string `json:"build_date,omitempty"`
+}
+
+// Manifest describes the content of an export and how it has been produced
+type Manifest struct {
+	Username    string            `json:"username"`
+	Tool        ToolInfo          `json:"tool"`
+	StartedAt   time.Time         `json:"started_at"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Format      Format            `json:"format,omitempty"`
+	Compression Compression       `json:"compression,omitempty"`
+	Layout      Layout            `json:"layout,omitempty"`
+	Filters     map[string]string `json:"filters,omitempty"`
+	Archives    []string          `json:"archives,omitempty"`
+	Months      []ManifestMonth   `json:"months,omitempty"`
+	Games       int               `json:"games"`
+	Files       []ManifestFile    `json:"files"`
+}
+
+// ManifestMonth is the number of games exported for a month
+type ManifestMonth struct {
+	Month string `json:"month"`
+	Games int    `json:"games"`
+}
+
+// ManifestFile is a file written during an export
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Games  int    `json:"games"`
+	Size   int64  `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// NewManifest returns an empty manifest for an export of username's games using opts
+func NewManifest(username string, opts Options) *Manifest {
+	return &Manifest{
+		Username:    username,
+		Tool:        Tool,
+		StartedAt:   time.Now().UTC(),
+		Format:      opts.Format,
+		Compression: opts.Compression,
+	}
+}
+
+// SetFilter records a filter applied to the export, empty values are ignored
+func (m *Manifest) SetFilter(name, value string) {
+	if value == "" {
+		return
+	}
+	if m.Filters == nil {
+		m.Filters = make(map[string]string)
+	}
+	m.Filters[name] = value
+}
+
+// AddArchive records a monthly archive included in the export
+func (m *Manifest) AddArchive(archive model.ChesscomArchive) {
+	m.Archives = append(m.Archives, archive.GetURL())
+}
+
+// AddGame counts an exported game
+func (m *Manifest) AddGame(game model.ChesscomGame) {
+	m.Games++
+	month := time.Unix(game.EndTime, 0).UTC().Format("2006-01")
+	for i := range m.Months {
+		if m.Months[i].Month == month {
+			m.Months[i].Games++
+			return
+		}
+	}
+	m.Months = append(m.Months, ManifestMonth{Month: month, Games: 1})
+}
+
+// AddFile records the file at dir/path along with its size and checksum.
+// path is stored relative to dir, which is expected to be the manifest's directory.
+func (m *Manifest) AddFile(dir, path string, games int) error {
+	f, err := os.Open(filepath.Join(dir, path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	size, sum, err := checksum(f)
+	if err != nil {
+		return err
+	}
+	m.Files = append(m.Files, ManifestFile{
+		Path:   filepath.ToSlash(path),
+		Games:  games,
+		Size:   size,
+		SHA256: sum,
+	})
+	return nil
+}
+
+// Encode writes the manifest as indented JSON to w, the creation date is set if not already
+func (m *Manifest) Encode(w io.Writer) error {
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now().UTC()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// WriteFile writes the manifest to path
+func (m *Manifest) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := m.Encode(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func checksum(r io.Reader) (int64, string, error) {
+	h := sha256.New()
+	size, err := io.Copy(h, r)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyResult is the result of the verification of a single file of a manifest
+type VerifyResult struct {
+	Path string
+	Err  error
+}
+
+// VerifyManifest checks all files listed in a manifest against their size and checksum.
+// path is either a manifest file, a directory containing a manifest.json or a zip bundle.
+func VerifyManifest(path string) ([]VerifyResult, error) {
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		return verifyZipBundle(path)
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, ManifestName)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := Manifest{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s, err=%v", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	results := make([]VerifyResult, 0, len(m.Files))
+	for _, file := range m.Files {
+		results = append(results, VerifyResult{
+			Path: file.Path,
+			Err: verifyFile(file, func() (io.ReadCloser, error) {
+				return os.Open(filepath.Join(dir, filepath.FromSlash(file.Path)))
+			}),
+		})
+	}
+	return results, nil
+}
+
+func verifyZipBundle(path string) ([]VerifyResult, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	entries := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+	mf, ok := entries[ManifestName]
+	if !ok {
+		return nil, fmt.Errorf("no %s found in %s", ManifestName, path)
+	}
+	r, err := mf.Open()
+	if err != nil {
+		return nil, err
+	}
+	m := Manifest{}
+	err = json.NewDecoder(r).Decode(&m)
+	r.Close()
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest in %s, err=%v", path, err)
+	}
+
+	results := make([]VerifyResult, 0, len(m.Files))
+	for _, file := range m.Files {
+		entry := entries[file.Path]
+		results = append(results, VerifyResult{
+			Path: file.Path,
+			Err: verifyFile(file, func() (io.ReadCloser, error) {
+				if entry == nil {
+					return nil, fmt.Errorf("file not found in archive")
+				}
+				return entry.Open()
+			}),
+		})
+	}
+	return results, nil
+}
+
+func verifyFile(file ManifestFile, open func() (io.ReadCloser, error)) error {
+	r, err := open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	size, sum, err := checksum(r)
+	if err != nil {
+		return err
+	}
+	if file.Size != 0 && size != file.Size {
+		return fmt.Errorf("size mismatch, expected %d bytes, got %d", file.Size, size)
+	}
+	if file.SHA256 != "" && sum != file.SHA256 {
+		return fmt.Errorf("checksum mismatch, expected %s, got %s", file.SHA256, sum)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package exporter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManifest_AddGame(t *testing.T) {
+	m := NewManifest("erik", Options{Format: FormatPGN})
+	for _, end := range []time.Time{
+		time.Date(2021, time.July, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, time.August, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, time.July, 31, 23, 59, 0, 0, time.UTC),
+	} {
+		game := sampleGame()
+		game.EndTime = end.Unix()
+		m.AddGame(game)
+	}
+
+	if m.Games != 3 || len(m.Months) != 2 || m.Months[0].Month != "2021-07" || m.Months[0].Games != 2 {
+		t.Errorf("unexpected manifest months %+v", m.Months)
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewSplitWriter(LayoutOptions{Layout: LayoutYear, Dir: dir}, Options{
+		Format:   FormatPGN,
+		Username: "erik",
+		Manifest: NewManifest("erik", Options{Format: FormatPGN}),
+	})
+	if err != nil {
+		t.Fatalf("NewSplitWriter() error = %v", err)
+	}
+	if err := w.Write(sampleGame()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	m := w.(*splitWriter).opts.Manifest
+	if len(m.Files) != 1 || m.Files[0].Path != "erik/2021.pgn" || m.Files[0].SHA256 == "" {
+		t.Fatalf("unexpected manifest files %+v", m.Files)
+	}
+	if err := m.WriteFile(filepath.Join(dir, ManifestName)); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := VerifyManifest(dir)
+	if err != nil || len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("VerifyManifest() = %+v, %v", results, err)
+	}
+
+	// Tampering with the exported file
+	if err := ioutil.WriteFile(filepath.Join(dir, "erik", "2021.pgn"), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results, err = VerifyManifest(filepath.Join(dir, ManifestName))
+	if err != nil || len(results) != 1 || results[0].Err == nil {
+		t.Errorf("VerifyManifest() expected a failure, got %+v, %v", results, err)
+	}
+}
+
+func TestVerifyManifest_ZipBundle(t *testing.T) {
+	buf := bytes.Buffer{}
+	w, err := NewZipBundleWriter(&buf, LayoutOptions{Layout: LayoutMonth}, Options{Format: FormatPGN, Username: "erik"})
+	if err != nil {
+		t.Fatalf("NewZipBundleWriter() error = %v", err)
+	}
+	if err := w.Write(sampleGame()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results, err := VerifyManifest(path)
+	if err != nil || len(results) != 1 || results[0].Err != nil {
+		t.Errorf("VerifyManifest() = %+v, %v", results, err)
+	}
+
+	if _, err := VerifyManifest(filepath.Join(t.TempDir(), "missing", ManifestName)); !os.IsNotExist(err) {
+		t.Errorf("VerifyManifest() expected a not exist error, got %v", err)
+	}
+}
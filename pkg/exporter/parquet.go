@@ -0,0 +1,141 @@
+package exporter
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+	"io"
+)
+
+// parquetConcurrency is the number of goroutines used to encode parquet pages
+const parquetConcurrency = 4
+
+// parquetGame is the schema of a game row
+type parquetGame struct {
+	URL           string  `parquet:"name=url, type=BYTE_ARRAY, convertedtype=UTF8"`
+	UUID          string  `parquet:"name=uuid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	EndTime       int64   `parquet:"name=end_time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	TimeClass     string  `parquet:"name=time_class, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	TimeControl   string  `parquet:"name=time_control, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Rules         string  `parquet:"name=rules, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Rated         bool    `parquet:"name=rated, type=BOOLEAN"`
+	White         string  `parquet:"name=white, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Black         string  `parquet:"name=black, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	WhiteRating   int32   `parquet:"name=white_rating, type=INT32"`
+	BlackRating   int32   `parquet:"name=black_rating, type=INT32"`
+	WhiteResult   string  `parquet:"name=white_result, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	BlackResult   string  `parquet:"name=black_result, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Result        string  `parquet:"name=result, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Termination   string  `parquet:"name=termination, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ECO           string  `parquet:"name=eco, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Opening       string  `parquet:"name=opening, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	WhiteAccuracy float64 `parquet:"name=white_accuracy, type=DOUBLE"`
+	BlackAccuracy float64 `parquet:"name=black_accuracy, type=DOUBLE"`
+	Plies         int32   `parquet:"name=plies, type=INT32"`
+	PGN           string  `parquet:"name=pgn, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// parquetMove is the schema of a move row
+type parquetMove struct {
+	GameURL string `parquet:"name=game_url, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Ply     int32  `parquet:"name=ply, type=INT32"`
+	Number  int32  `parquet:"name=number, type=INT32"`
+	Color   string `parquet:"name=color, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	SAN     string `parquet:"name=san, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ClockMs *int64 `parquet:"name=clock_ms, type=INT64, repetitiontype=OPTIONAL"`
+}
+
+// parquetWriter writes one row per game and, optionally, one row per move to a second output.
+// Rows are flushed by row groups so that memory usage does not depend on the number of games.
+type parquetWriter struct {
+	games *writer.ParquetWriter
+	moves *writer.ParquetWriter
+}
+
+// NewParquetWriter returns a GameWriter writing games to w and moves to movesWriter.
+// movesWriter can be nil if moves are not needed.
+func NewParquetWriter(w io.Writer, movesWriter io.Writer) (GameWriter, error) {
+	games, err := writer.NewParquetWriterFromWriter(w, new(parquetGame), parquetConcurrency)
+	if err != nil {
+		return nil, err
+	}
+	games.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	p := &parquetWriter{games: games}
+	if movesWriter != nil {
+		p.moves, err = writer.NewParquetWriterFromWriter(movesWriter, new(parquetMove), parquetConcurrency)
+		if err != nil {
+			return nil, err
+		}
+		p.moves.CompressionType = parquet.CompressionCodec_SNAPPY
+	}
+	return p, nil
+}
+
+func (p *parquetWriter) Write(game model.ChesscomGame) error {
+	g, err := pgn.Parse(game.PGN)
+	if err != nil {
+		return fmt.Errorf("unable to parse PGN of game %s, err=%v", game.URL, err)
+	}
+	opening := GameOpening(g)
+
+	err = p.games.Write(parquetGame{
+		URL:           game.URL,
+		UUID:          game.UUID,
+		EndTime:       game.EndTime * 1000,
+		TimeClass:     game.TimeClass,
+		TimeControl:   g.Tag("TimeControl"),
+		Rules:         game.Rules,
+		Rated:         game.Rated,
+		White:         game.White.Username,
+		Black:         game.Black.Username,
+		WhiteRating:   int32(game.White.Rating),
+		BlackRating:   int32(game.Black.Rating),
+		WhiteResult:   game.White.Result,
+		BlackResult:   game.Black.Result,
+		Result:        g.Result,
+		Termination:   g.Tag("Termination"),
+		ECO:           opening.ECO,
+		Opening:       opening.Name,
+		WhiteAccuracy: game.Accuracies.White,
+		BlackAccuracy: game.Accuracies.Black,
+		Plies:         int32(len(g.Moves)),
+		PGN:           game.PGN,
+	})
+	if err != nil || p.moves == nil {
+		return err
+	}
+
+	for i, m := range g.Moves {
+		row := parquetMove{
+			GameURL: game.URL,
+			Ply:     int32(i + 1),
+			Number:  int32(m.Number),
+			Color:   model.ColorWhite,
+			SAN:     m.SAN,
+		}
+		if !m.White {
+			row.Color = model.ColorBlack
+		}
+		if d, ok := m.Clock(); ok {
+			ms := d.Milliseconds()
+			row.ClockMs = &ms
+		}
+		if err := p.moves.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	if err := p.games.WriteStop(); err != nil {
+		return err
+	}
+	if p.moves != nil {
+		return p.moves.WriteStop()
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package exporter
+
+import (
+	"bytes"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+	"testing"
+)
+
+func TestParquetWriter(t *testing.T) {
+	games, moves := bytes.Buffer{}, bytes.Buffer{}
+	w, err := NewParquetWriter(&games, &moves)
+	if err != nil {
+		t.Fatalf("NewParquetWriter() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := w.Write(sampleGame()); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	gameRows := make([]parquetGame, 2)
+	readParquet(t, games.Bytes(), new(parquetGame), &gameRows, 2)
+	if g := gameRows[1]; g.White != "erik" || g.BlackRating != 3200 || g.Opening != "King's Knight Opening" || g.Plies != 3 {
+		t.Errorf("unexpected game row %+v", g)
+	}
+
+	moveRows := make([]parquetMove, 6)
+	readParquet(t, moves.Bytes(), new(parquetMove), &moveRows, 6)
+	if m := moveRows[1]; m.SAN != "e5" || m.Color != "black" || m.ClockMs == nil || *m.ClockMs != 179100 {
+		t.Errorf("unexpected move row %+v", m)
+	}
+}
+
+func readParquet(t *testing.T, data []byte, schema interface{}, rows interface{}, want int64) {
+	t.Helper()
+	f, err := buffer.NewBufferFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := reader.NewParquetReader(f, schema, 1)
+	if err != nil {
+		t.Fatalf("NewParquetReader() error = %v", err)
+	}
+	defer r.ReadStop()
+	if got := r.GetNumRows(); got != want {
+		t.Fatalf("got %d rows, want %d", got, want)
+	}
+	if err := r.Read(rows); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+package exporter
+
+import (
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"io"
+	"strings"
+)
+
+type pgnWriter struct {
+	w io.Writer
+}
+
+func (p *pgnWriter) Write(game model.ChesscomGame) error {
+	// Games are separated by an empty line
+	_, err := io.WriteString(p.w, strings.TrimRight(game.PGN, "\n")+"\n\n")
+	return err
+}
+
+func (p *pgnWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,91 @@
+package exporter
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"io"
+	"os"
+)
+
+// ReadGames decodes raw games written with the json or ndjson format and calls fn for each of them,
+// it stops at the first error
+func ReadGames(r io.Reader, fn func(game model.ChesscomGame) error) error {
+	br := bufio.NewReader(r)
+	first, err := firstNonSpace(br)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(br)
+	if first == '[' { // json array, ndjson otherwise
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	for dec.More() {
+		game := model.ChesscomGame{}
+		if err := dec.Decode(&game); err != nil {
+			return fmt.Errorf("unable to decode game, err=%v", err)
+		}
+		if err := fn(game); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func firstNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\n' && b != '\r' && b != '\t' {
+			return b, br.UnreadByte()
+		}
+	}
+}
+
+// ReadGamesFile reads games from a json or ndjson export, optionally compressed with gzip or zstd
+func ReadGamesFile(path string, fn func(game model.ChesscomGame) error) error {
+	if f := FormatFromPath(path); f != FormatJSON && f != FormatNDJSON {
+		return fmt.Errorf("unable to read games from %s, only json and ndjson exports can be read", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := NewDecompressedReader(f, CompressionFromPath(path))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return ReadGames(r, fn)
+}
+
+// NewDecompressedReader returns a reader decompressing data read from r.
+// Closing the returned reader does not close r.
+func NewDecompressedReader(r io.Reader, c Compression) (io.ReadCloser, error) {
+	switch c {
+	case CompressionNone, "":
+		return io.NopCloser(r), nil
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("compression %q cannot be read as a single stream", c)
+}
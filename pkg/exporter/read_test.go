@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadGamesFile(t *testing.T) {
+	tests := []string{"games.json", "games.ndjson", "games.json.gz", "games.jsonl.zst", "empty.json"}
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			w, err := Create(path, Options{Format: FormatFromPath(path), Compression: CompressionFromPath(path)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := 2
+			if name == "empty.json" {
+				want = 0
+			}
+			for i := 0; i < want; i++ {
+				if err := w.Write(sampleGame()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			var games []model.ChesscomGame
+			err = ReadGamesFile(path, func(game model.ChesscomGame) error {
+				games = append(games, game)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ReadGamesFile() error = %v", err)
+			}
+			if len(games) != want {
+				t.Fatalf("got %d games, want %d", len(games), want)
+			}
+			if want > 0 && (games[1].URL != sampleGame().URL || games[1].Black.Rating != 3200) {
+				t.Errorf("unexpected game %+v", games[1])
+			}
+		})
+	}
+
+	if err := ReadGamesFile("games.pgn", func(model.ChesscomGame) error { return nil }); err == nil {
+		t.Errorf("ReadGamesFile() expected an error for a PGN file")
+	}
+}
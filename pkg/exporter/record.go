@@ -0,0 +1,103 @@
+package exporter
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/openings"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"time"
+)
+
+// Record is a normalized game seen from a player's perspective
+type Record struct {
+	URL              string    `json:"url"`
+	UUID             string    `json:"uuid"`
+	EndTime          time.Time `json:"end_time"`
+	TimeClass        string    `json:"time_class"`
+	TimeControl      string    `json:"time_control"`
+	Rules            string    `json:"rules"`
+	Rated            bool      `json:"rated"`
+	Player           string    `json:"player"`
+	Color            string    `json:"color"`
+	Rating           int       `json:"rating"`
+	Opponent         string    `json:"opponent"`
+	OpponentRating   int       `json:"opponent_rating"`
+	Result           string    `json:"result"`
+	ResultCode       string    `json:"result_code"`
+	OpponentCode     string    `json:"opponent_result_code"`
+	Termination      string    `json:"termination"`
+	ECO              string    `json:"eco"`
+	Opening          string    `json:"opening"`
+	OpeningFamily    string    `json:"opening_family"`
+	ChesscomECO      string    `json:"chesscom_eco"`
+	ChesscomOpening  string    `json:"chesscom_opening"`
+	Accuracy         float64   `json:"accuracy"`
+	OpponentAccuracy float64   `json:"opponent_accuracy"`
+	Moves            []string  `json:"moves"`
+}
+
+// NewRecord builds a normalized record of game from username's perspective.
+// If username did not play the game, white's perspective is used.
+func NewRecord(game model.ChesscomGame, username string) (*Record, error) {
+	p, err := pgn.Parse(game.PGN)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse PGN of game %s, err=%v", game.URL, err)
+	}
+
+	color := game.PlayerColor(username)
+	if color == "" {
+		color = model.ColorWhite
+	}
+	player := game.Player(color)
+	opponent := game.Opponent(color)
+	opening := GameOpening(p)
+
+	return &Record{
+		URL:              game.URL,
+		UUID:             game.UUID,
+		EndTime:          time.Unix(game.EndTime, 0).UTC(),
+		TimeClass:        game.TimeClass,
+		TimeControl:      p.Tag("TimeControl"),
+		Rules:            game.Rules,
+		Rated:            game.Rated,
+		Player:           player.Username,
+		Color:            color,
+		Rating:           player.Rating,
+		Opponent:         opponent.Username,
+		OpponentRating:   opponent.Rating,
+		Result:           player.Outcome(),
+		ResultCode:       player.Result,
+		OpponentCode:     opponent.Result,
+		Termination:      p.Tag("Termination"),
+		ECO:              opening.ECO,
+		Opening:          opening.Name,
+		OpeningFamily:    opening.Family(),
+		ChesscomECO:      p.Tag("ECO"),
+		ChesscomOpening:  OpeningName(p.Tag("ECOUrl")),
+		Accuracy:         game.Accuracy(color),
+		OpponentAccuracy: game.Accuracy(opponentColor(color)),
+		Moves:            p.SANs(),
+	}, nil
+}
+
+// GameOpening returns the opening of a game classified using the embedded openings database,
+// chess.com's ECO and ECOUrl tags are used for games which cannot be classified
+func GameOpening(g *pgn.Game) openings.Opening {
+	if o, ok := openings.Classify(g); ok {
+		return o
+	}
+	return openings.Opening{ECO: g.Tag("ECO"), Name: OpeningName(g.Tag("ECOUrl"))}
+}
+
+// OpeningName converts a chess.com opening URL to a human readable opening name
+// e.g. https://www.chess.com/openings/Italian-Game-Two-Knights-Defense gives "Italian Game Two Knights Defense"
+func OpeningName(ecoURL string) string {
+	return pgn.OpeningName(ecoURL)
+}
+
+func opponentColor(color string) string {
+	if color == model.ColorBlack {
+		return model.ColorWhite
+	}
+	return model.ColorBlack
+}
@@ -0,0 +1,155 @@
+package exporter
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	_ "modernc.org/sqlite" // pure Go driver, keeps the binary cross-compilable
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS players (
+	username TEXT PRIMARY KEY COLLATE NOCASE,
+	uuid     TEXT,
+	url      TEXT
+);
+
+CREATE TABLE IF NOT EXISTS games (
+	url            TEXT PRIMARY KEY,
+	uuid           TEXT,
+	end_time       INTEGER NOT NULL,
+	time_class     TEXT,
+	time_control   TEXT,
+	rules          TEXT,
+	rated          INTEGER NOT NULL,
+	white          TEXT NOT NULL REFERENCES players(username),
+	black          TEXT NOT NULL REFERENCES players(username),
+	white_rating   INTEGER,
+	black_rating   INTEGER,
+	white_result   TEXT,
+	black_result   TEXT,
+	result         TEXT,
+	termination    TEXT,
+	eco            TEXT,
+	opening        TEXT,
+	white_accuracy REAL,
+	black_accuracy REAL,
+	initial_setup  TEXT,
+	fen            TEXT,
+	pgn            TEXT
+);
+CREATE INDEX IF NOT EXISTS games_white_idx ON games(white);
+CREATE INDEX IF NOT EXISTS games_black_idx ON games(black);
+CREATE INDEX IF NOT EXISTS games_end_time_idx ON games(end_time);
+
+CREATE TABLE IF NOT EXISTS moves (
+	game_url TEXT NOT NULL REFERENCES games(url) ON DELETE CASCADE,
+	ply      INTEGER NOT NULL,
+	number   INTEGER NOT NULL,
+	color    TEXT NOT NULL,
+	san      TEXT NOT NULL,
+	clock_ms INTEGER,
+	comment  TEXT,
+	PRIMARY KEY (game_url, ply)
+);
+`
+
+const (
+	sqliteUpsertPlayer = `
+INSERT INTO players (username, uuid, url) VALUES (?, ?, ?)
+ON CONFLICT(username) DO UPDATE SET uuid = excluded.uuid, url = excluded.url`
+
+	sqliteUpsertGame = `
+INSERT INTO games (url, uuid, end_time, time_class, time_control, rules, rated, white, black,
+	white_rating, black_rating, white_result, black_result, result, termination, eco, opening,
+	white_accuracy, black_accuracy, initial_setup, fen, pgn)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(url) DO UPDATE SET
+	uuid = excluded.uuid, end_time = excluded.end_time, time_class = excluded.time_class,
+	time_control = excluded.time_control, rules = excluded.rules, rated = excluded.rated,
+	white = excluded.white, black = excluded.black, white_rating = excluded.white_rating,
+	black_rating = excluded.black_rating, white_result = excluded.white_result,
+	black_result = excluded.black_result, result = excluded.result, termination = excluded.termination,
+	eco = excluded.eco, opening = excluded.opening, white_accuracy = excluded.white_accuracy,
+	black_accuracy = excluded.black_accuracy, initial_setup = excluded.initial_setup,
+	fen = excluded.fen, pgn = excluded.pgn`
+
+	sqliteInsertMove = `
+INSERT INTO moves (game_url, ply, number, color, san, clock_ms, comment) VALUES (?, ?, ?, ?, ?, ?, ?)`
+)
+
+// sqliteWriter writes games, players and moves to a SQLite database.
+// Games already present are updated so that the same database can be used across runs.
+type sqliteWriter struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewSQLiteWriter opens (or creates) the SQLite database at path and returns a GameWriter to it.
+// All games are written in a single transaction committed on Close.
+func NewSQLiteWriter(path string) (GameWriter, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to create database schema, err=%v", err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteWriter{db: db, tx: tx}, nil
+}
+
+func (s *sqliteWriter) Write(game model.ChesscomGame) error {
+	p, err := pgn.Parse(game.PGN)
+	if err != nil {
+		return fmt.Errorf("unable to parse PGN of game %s, err=%v", game.URL, err)
+	}
+	opening := GameOpening(p)
+
+	for _, player := range []model.ChesscomPlayerInfo{game.White, game.Black} {
+		if _, err := s.tx.Exec(sqliteUpsertPlayer, player.Username, player.UUID, player.URL); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.tx.Exec(sqliteUpsertGame,
+		game.URL, game.UUID, game.EndTime, game.TimeClass, p.Tag("TimeControl"), game.Rules, game.Rated,
+		game.White.Username, game.Black.Username, game.White.Rating, game.Black.Rating,
+		game.White.Result, game.Black.Result, p.Result, p.Tag("Termination"),
+		opening.ECO, opening.Name, game.Accuracies.White, game.Accuracies.Black,
+		game.InitialSetup, game.FEN, game.PGN)
+	if err != nil {
+		return err
+	}
+
+	// Moves are fully rewritten on update
+	if _, err := s.tx.Exec("DELETE FROM moves WHERE game_url = ?", game.URL); err != nil {
+		return err
+	}
+	for i, m := range p.Moves {
+		color := model.ColorWhite
+		if !m.White {
+			color = model.ColorBlack
+		}
+		var clock sql.NullInt64
+		if d, ok := m.Clock(); ok {
+			clock = sql.NullInt64{Int64: d.Milliseconds(), Valid: true}
+		}
+		if _, err := s.tx.Exec(sqliteInsertMove, game.URL, i+1, m.Number, color, m.SAN, clock, m.Comment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sqliteWriter) Close() error {
+	defer s.db.Close()
+	return s.tx.Commit()
+}
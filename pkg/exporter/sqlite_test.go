@@ -0,0 +1,58 @@
+package exporter
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteWriter_Upsert(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.db")
+
+	// Writing the same game twice in two runs must not duplicate it
+	for run, rating := range []int{1500, 1510} {
+		w, err := Create(path, Options{Format: FormatSQLite})
+		if err != nil {
+			t.Fatalf("run %d: Create() error = %v", run, err)
+		}
+		game := sampleGame()
+		game.White.Rating = rating
+		if err := w.Write(game); err != nil {
+			t.Fatalf("run %d: Write() error = %v", run, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("run %d: Close() error = %v", run, err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		name  string
+		query string
+		want  interface{}
+	}{
+		{name: "games", query: "SELECT COUNT(*) FROM games", want: int64(1)},
+		{name: "players", query: "SELECT COUNT(*) FROM players", want: int64(2)},
+		{name: "moves", query: "SELECT COUNT(*) FROM moves", want: int64(3)},
+		{name: "updated rating", query: "SELECT white_rating FROM games", want: int64(1510)},
+		{name: "opening", query: "SELECT opening FROM games", want: "King's Knight Opening"},
+		{name: "clock", query: "SELECT clock_ms FROM moves WHERE ply = 2", want: int64(179100)},
+		{name: "black move", query: "SELECT color || ':' || san FROM moves WHERE ply = 2", want: "black:e5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got interface{}
+			if err := db.QueryRow(tt.query).Scan(&got); err != nil {
+				t.Fatalf("query error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package exporter
+
+import (
+	"archive/zip"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// zipBundleWriter splits games across files like a split writer and packs them,
+// along with their manifest, into a single zip archive once closed.
+// Files are staged in a temporary directory as a zip archive can only be written one file at a time.
+type zipBundleWriter struct {
+	w     io.Writer
+	split *splitWriter
+	opts  Options
+}
+
+// NewZipBundleWriter returns a GameWriter writing a zip archive to w, games are split across files
+// inside the archive according to layout (layout.Dir is ignored).
+func NewZipBundleWriter(w io.Writer, layout LayoutOptions, opts Options) (GameWriter, error) {
+	dir, err := ioutil.TempDir("", "chesscom-export-")
+	if err != nil {
+		return nil, err
+	}
+	layout.Dir = dir
+	if opts.Manifest == nil {
+		opts.Manifest = NewManifest(opts.Username, opts)
+	}
+	opts.Compression = CompressionNone // files are compressed by the archive itself
+
+	split, err := newSplitWriter(layout, opts)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &zipBundleWriter{w: w, split: split, opts: opts}, nil
+}
+
+func (z *zipBundleWriter) Write(game model.ChesscomGame) error {
+	return z.split.Write(game)
+}
+
+func (z *zipBundleWriter) Close() error {
+	defer os.RemoveAll(z.split.layout.Dir)
+	if err := z.split.Close(); err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(z.w)
+	for _, f := range z.opts.Manifest.Files {
+		if err := addZipFile(zw, f.Path, filepath.Join(z.split.layout.Dir, filepath.FromSlash(f.Path))); err != nil {
+			return err
+		}
+	}
+
+	mw, err := zw.Create(ManifestName)
+	if err != nil {
+		return err
+	}
+	if err := z.opts.Manifest.Encode(mw); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func addZipFile(zw *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
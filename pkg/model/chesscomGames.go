@@ -1,5 +1,12 @@
 package model
 
+import "strings"
+
+const (
+	ColorWhite = "white"
+	ColorBlack = "black"
+)
+
 type ChesscomGames struct {
 	Games []ChesscomGame `json:"games"`
 }
@@ -22,3 +29,39 @@ type ChesscomGame struct {
 	White        ChesscomPlayerInfo `json:"white"`
 	Black        ChesscomPlayerInfo `json:"black"`
 }
+
+// PlayerColor returns the color played by username in this game,
+// an empty string is returned if the player did not take part in the game
+func (g ChesscomGame) PlayerColor(username string) string {
+	switch {
+	case strings.EqualFold(g.White.Username, username):
+		return ColorWhite
+	case strings.EqualFold(g.Black.Username, username):
+		return ColorBlack
+	}
+	return ""
+}
+
+// Player returns the player's info for the given color
+func (g ChesscomGame) Player(color string) ChesscomPlayerInfo {
+	if color == ColorBlack {
+		return g.Black
+	}
+	return g.White
+}
+
+// Opponent returns the player's info of the opponent of the given color
+func (g ChesscomGame) Opponent(color string) ChesscomPlayerInfo {
+	if color == ColorBlack {
+		return g.White
+	}
+	return g.Black
+}
+
+// Accuracy returns chess.com's accuracy of the given color
+func (g ChesscomGame) Accuracy(color string) float64 {
+	if color == ColorBlack {
+		return g.Accuracies.Black
+	}
+	return g.Accuracies.White
+}
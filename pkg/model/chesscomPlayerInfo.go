@@ -1,5 +1,11 @@
 package model
 
+const (
+	OutcomeWin  = "win"
+	OutcomeDraw = "draw"
+	OutcomeLoss = "loss"
+)
+
 type ChesscomPlayerInfo struct {
 	Rating   int    `json:"rating"`
 	Result   string `json:"result"`
@@ -7,3 +13,15 @@ type ChesscomPlayerInfo struct {
 	Username string `json:"username"`
 	UUID     string `json:"uuid"`
 }
+
+// Outcome converts chess.com's result code (win, checkmated, agreed, timeout, etc.)
+// to either a win, a draw or a loss for this player
+func (p ChesscomPlayerInfo) Outcome() string {
+	switch p.Result {
+	case "win":
+		return OutcomeWin
+	case "agreed", "repetition", "stalemate", "insufficient", "50move", "timevsinsufficient":
+		return OutcomeDraw
+	}
+	return OutcomeLoss
+}
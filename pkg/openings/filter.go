@@ -0,0 +1,56 @@
+package openings
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ecoPatternRegexp = regexp.MustCompile(`^[A-E](\d{0,2})$`)
+
+// ECOPattern matches ECO codes: a single code (B90), a prefix (B9 or B) or a range of codes (B20-B99)
+type ECOPattern struct {
+	from, to string
+}
+
+// ParseECOPatterns parses a comma separated list of ECO patterns
+func ParseECOPatterns(s string) ([]ECOPattern, error) {
+	var patterns []ECOPattern
+	for _, p := range strings.Split(strings.ToUpper(s), ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		bounds := strings.SplitN(p, "-", 2)
+		for _, b := range bounds {
+			if !ecoPatternRegexp.MatchString(b) {
+				return nil, fmt.Errorf("invalid ECO pattern %q, expected a code (B90), a prefix (B9) or a range (B20-B99)", p)
+			}
+		}
+		pattern := ECOPattern{from: bounds[0], to: bounds[len(bounds)-1]}
+		if pattern.from > pattern.to {
+			return nil, fmt.Errorf("invalid ECO range %q", p)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// Match returns true if the ECO code matches the pattern
+func (p ECOPattern) Match(eco string) bool {
+	eco = strings.ToUpper(eco)
+	return eco >= p.from && (eco <= p.to || strings.HasPrefix(eco, p.to))
+}
+
+// MatchAny returns true if the ECO code matches one of the patterns or if there is no pattern
+func MatchAny(patterns []ECOPattern, eco string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if p.Match(eco) {
+			return true
+		}
+	}
+	return false
+}
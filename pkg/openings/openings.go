@@ -0,0 +1,109 @@
+// Package openings classifies games using the lichess chess-openings database
+// (https://github.com/lichess-org/chess-openings, public domain), embedded in the binary.
+package openings
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"strings"
+	"sync"
+)
+
+//go:embed openings.tsv
+var openingsTSV string
+
+// Opening is an entry of the openings database
+type Opening struct {
+	ECO  string `json:"eco"`
+	Name string `json:"name"`
+	// PGN is the shortest sequence of moves reaching the opening's position
+	PGN string `json:"pgn"`
+	// Ply is the number of half-moves of PGN
+	Ply int `json:"ply"`
+}
+
+// Family returns the name of the opening without its variation
+// e.g. "Sicilian Defense" for "Sicilian Defense: Najdorf Variation"
+func (o Opening) Family() string {
+	if idx := strings.Index(o.Name, ":"); idx >= 0 {
+		return o.Name[:idx]
+	}
+	return o.Name
+}
+
+var (
+	loadOnce sync.Once
+	byKey    map[string]Opening
+	maxPly   int
+)
+
+// load indexes the embedded database by position, it panics if the embedded data is invalid
+func load() {
+	r := csv.NewReader(strings.NewReader(openingsTSV))
+	r.Comma = '\t'
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("invalid openings database, err=%v", err))
+	}
+
+	byKey = make(map[string]Opening, len(records))
+	for _, row := range records[1:] { // skip the header: eco, name, pgn, uci, epd
+		o := Opening{ECO: row[0], Name: row[1], PGN: row[2], Ply: len(strings.Fields(row[3]))}
+		key := positionKey(row[4])
+		// Several move orders can lead to the same position, the first entry is kept
+		if _, ok := byKey[key]; !ok {
+			byKey[key] = o
+		}
+		if o.Ply > maxPly {
+			maxPly = o.Ply
+		}
+	}
+}
+
+// positionKey returns the key of a position given as FEN or EPD: the placement of pieces,
+// the side to move and castling rights (en passant squares are inconsistently set across tools)
+func positionKey(fen string) string {
+	fields := strings.Fields(fen)
+	if len(fields) > 3 {
+		fields = fields[:3]
+	}
+	return strings.Join(fields, " ")
+}
+
+// Lookup returns the opening of a position given as FEN or EPD
+func Lookup(fen string) (Opening, bool) {
+	loadOnce.Do(load)
+	o, ok := byKey[positionKey(fen)]
+	return o, ok
+}
+
+// Classify returns the opening of the deepest position of the game found in the database,
+// false is returned if no position matches or if the game cannot be replayed
+func Classify(g *pgn.Game) (Opening, bool) {
+	loadOnce.Do(load)
+	positions, err := g.Positions()
+	if err != nil {
+		return Opening{}, false
+	}
+	if len(positions) > maxPly+1 {
+		positions = positions[:maxPly+1]
+	}
+	for i := len(positions) - 1; i > 0; i-- {
+		if o, ok := byKey[positionKey(positions[i].String())]; ok {
+			return o, true
+		}
+	}
+	return Opening{}, false
+}
+
+// ClassifyPGN parses a game and returns its opening as Classify does
+func ClassifyPGN(s string) (Opening, bool) {
+	g, err := pgn.Parse(s)
+	if err != nil {
+		return Opening{}, false
+	}
+	return Classify(g)
+}
@@ -0,0 +1,76 @@
+package openings
+
+import (
+	"testing"
+)
+
+func TestClassifyPGN(t *testing.T) {
+	tests := []struct {
+		name    string
+		pgn     string
+		eco     string
+		opening string
+		family  string
+		ok      bool
+	}{
+		{name: "najdorf", pgn: "1. e4 c5 2. Nf3 d6 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6 6. Be3 e5 7. Nb3 Be6 8. f3 *",
+			eco: "B90", opening: "Sicilian Defense: Najdorf Variation, English Attack", family: "Sicilian Defense", ok: true},
+		{name: "transposition", pgn: "1. Nf3 d5 2. d4 *", eco: "D02", opening: "Queen's Pawn Game: Zukertort Variation", family: "Queen's Pawn Game", ok: true},
+		{name: "deepest position after leaving theory", pgn: "1. e4 e5 2. Ke2 Ke7 3. Ke1 *", eco: "C20", opening: "Bongcloud Attack", family: "Bongcloud Attack", ok: true},
+		{name: "no move", pgn: "*", ok: false},
+		{name: "illegal move", pgn: "1. e5 *", ok: false},
+	}
+	for _, tt := range tests {
+		o, ok := ClassifyPGN(tt.pgn)
+		if ok != tt.ok {
+			t.Errorf("%s: ClassifyPGN() ok = %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if o.ECO != tt.eco || o.Name != tt.opening || o.Family() != tt.family {
+			t.Errorf("%s: ClassifyPGN() = %s %q (%q), want %s %q (%q)", tt.name, o.ECO, o.Name, o.Family(), tt.eco, tt.opening, tt.family)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	o, ok := Lookup("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	if !ok || o.ECO != "B00" || o.Name != "King's Pawn" || o.Ply != 1 {
+		t.Errorf("Lookup() = %+v, %v", o, ok)
+	}
+}
+
+func TestECOPattern(t *testing.T) {
+	tests := []struct {
+		patterns string
+		eco      string
+		want     bool
+		wantErr  bool
+	}{
+		{patterns: "", eco: "A00", want: true},
+		{patterns: "B90", eco: "B90", want: true},
+		{patterns: "B90", eco: "B91", want: false},
+		{patterns: "B9", eco: "B97", want: true},
+		{patterns: "b", eco: "B00", want: true},
+		{patterns: "B20-B99", eco: "B20", want: true},
+		{patterns: "B20-B99", eco: "B99", want: true},
+		{patterns: "B20-B99", eco: "B10", want: false},
+		{patterns: "C4-C5, A", eco: "C59", want: true},
+		{patterns: "C4-C5, A", eco: "C60", want: false},
+		{patterns: "C4-C5, A", eco: "A45", want: true},
+		{patterns: "Z00", wantErr: true},
+		{patterns: "B99-B20", wantErr: true},
+	}
+	for _, tt := range tests {
+		patterns, err := ParseECOPatterns(tt.patterns)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseECOPatterns(%q) error = %v, wantErr %v", tt.patterns, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := MatchAny(patterns, tt.eco); got != tt.want {
+			t.Errorf("MatchAny(%q, %s) = %v, want %v", tt.patterns, tt.eco, got, tt.want)
+		}
+	}
+}
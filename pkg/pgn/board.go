@@ -0,0 +1,64 @@
+package pgn
+
+import (
+	"fmt"
+	"github.com/notnil/chess"
+	"strings"
+)
+
+// StartingPosition returns the position the game starts from, given by the FEN tag if any
+func (g *Game) StartingPosition() (*chess.Position, error) {
+	if variant := g.Tag("Variant"); variant != "" && !strings.EqualFold(variant, "Standard") {
+		return nil, fmt.Errorf("unsupported variant %q", variant)
+	}
+	fen := g.Tag("FEN")
+	if fen == "" {
+		return chess.StartingPosition(), nil
+	}
+	pos := &chess.Position{}
+	if err := pos.UnmarshalText([]byte(fen)); err != nil {
+		return nil, fmt.Errorf("invalid FEN %q, err=%v", fen, err)
+	}
+	return pos, nil
+}
+
+// Positions replays the game and returns the starting position followed by the position after each move,
+// positions[i] being the position before Moves[i] is played
+func (g *Game) Positions() ([]*chess.Position, error) {
+	positions, _, err := g.Replay()
+	return positions, err
+}
+
+// Replay replays the game and returns its positions, as Positions does, along with the decoded moves
+func (g *Game) Replay() ([]*chess.Position, []*chess.Move, error) {
+	pos, err := g.StartingPosition()
+	if err != nil {
+		return nil, nil, err
+	}
+	positions := make([]*chess.Position, 0, len(g.Moves)+1)
+	positions = append(positions, pos)
+	moves := make([]*chess.Move, 0, len(g.Moves))
+	for i, m := range g.Moves {
+		move, err := chess.AlgebraicNotation{}.Decode(pos, m.SAN)
+		if err != nil {
+			return nil, nil, fmt.Errorf("illegal move %d. %s (ply %d), err=%v", m.Number, m.SAN, i+1, err)
+		}
+		pos = pos.Update(move)
+		positions = append(positions, pos)
+		moves = append(moves, move)
+	}
+	return positions, moves, nil
+}
+
+// IsBlunder returns true if the move is annotated as a blunder, either with the $4 NAG or with "??"
+func (m Move) IsBlunder() bool {
+	if strings.HasSuffix(m.SAN, "??") {
+		return true
+	}
+	for _, nag := range m.NAGs {
+		if nag == "$4" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package pgn
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SevenTagRoster lists the mandatory PGN tags in their standard order
+var SevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+var clockCommandRegexp = regexp.MustCompile(`\s*\[%clk\s[^\]]*\]\s*`)
+
+// NormalizeOptions configures how a game is rewritten
+type NormalizeOptions struct {
+	// Profile converts the game for a target tool before any other option is applied
+	Profile Profile
+	// StripComments removes all the comments of the movetext (clocks included)
+	StripComments bool
+	// StripClocks removes the [%clk] commands from comments, comments left empty are removed
+	StripClocks bool
+	// DropTags lists the tags to remove
+	DropTags []string
+	// RenameTags maps tag names to their new names
+	RenameTags map[string]string
+	// AddTags are set on the game, replacing any existing tag with the same name
+	AddTags []Tag
+	// SevenTagRosterFirst reorders tags so that the Seven Tag Roster comes first
+	SevenTagRosterFirst bool
+	// LineWidth wraps the movetext at this number of columns, no wrapping if <= 0
+	LineWidth int
+}
+
+// FormatOptions returns the options to use to serialize a game normalized with these options
+func (o NormalizeOptions) FormatOptions() FormatOptions {
+	return FormatOptions{
+		LineWidth:   o.LineWidth,
+		PadComments: o.Profile == ProfileLichess,
+	}
+}
+
+// IsZero returns true if these options do not change anything to a game
+func (o NormalizeOptions) IsZero() bool {
+	return (o.Profile == "" || o.Profile == ProfileChesscom) && !o.StripComments && !o.StripClocks && len(o.DropTags) == 0 && len(o.RenameTags) == 0 &&
+		len(o.AddTags) == 0 && !o.SevenTagRosterFirst && o.LineWidth <= 0
+}
+
+// Normalize rewrites the game in place according to opts.
+// The profile is applied first, then tags are dropped, renamed, added and finally reordered.
+func (g *Game) Normalize(opts NormalizeOptions) {
+	if opts.Profile == ProfileLichess {
+		g.toLichess()
+	}
+
+	for i := range g.Moves {
+		m := &g.Moves[i]
+		switch {
+		case opts.StripComments:
+			m.Comment = ""
+		case opts.StripClocks:
+			m.Comment = strings.TrimSpace(clockCommandRegexp.ReplaceAllString(m.Comment, " "))
+		}
+	}
+
+	for _, name := range opts.DropTags {
+		g.RemoveTag(name)
+	}
+	for i := range g.Tags {
+		if name, ok := opts.RenameTags[g.Tags[i].Name]; ok {
+			g.Tags[i].Name = name
+		}
+	}
+	for _, t := range opts.AddTags {
+		g.SetTag(t.Name, t.Value)
+	}
+
+	if opts.SevenTagRosterFirst {
+		tags := make([]Tag, 0, len(g.Tags))
+		for _, name := range SevenTagRoster {
+			for _, t := range g.Tags {
+				if t.Name == name {
+					tags = append(tags, t)
+				}
+			}
+		}
+		for _, t := range g.Tags {
+			if !isSevenTagRoster(t.Name) {
+				tags = append(tags, t)
+			}
+		}
+		g.Tags = tags
+	}
+}
+
+func isSevenTagRoster(name string) bool {
+	for _, n := range SevenTagRoster {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTag sets the value of a tag, the tag is appended if it does not exist yet
+func (g *Game) SetTag(name, value string) {
+	for i := range g.Tags {
+		if g.Tags[i].Name == name {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+	g.Tags = append(g.Tags, Tag{Name: name, Value: value})
+}
+
+// RemoveTag removes a tag from the game
+func (g *Game) RemoveTag(name string) {
+	tags := g.Tags[:0]
+	for _, t := range g.Tags {
+		if t.Name != name {
+			tags = append(tags, t)
+		}
+	}
+	g.Tags = tags
+}
+
+// ParseTagList parses a comma separated list of tag names
+func ParseTagList(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ParseTagPairs parses a comma separated list of name=value pairs
+func ParseTagPairs(s string) ([]Tag, error) {
+	var tags []Tag
+	for _, pair := range ParseTagList(s) {
+		idx := strings.Index(pair, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid tag %q, expected name=value", pair)
+		}
+		tags = append(tags, Tag{Name: strings.TrimSpace(pair[:idx]), Value: strings.TrimSpace(pair[idx+1:])})
+	}
+	return tags, nil
+}
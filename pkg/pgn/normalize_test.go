@@ -0,0 +1,94 @@
+package pgn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGame_Format(t *testing.T) {
+	g, err := Parse(samplePGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Formatting and parsing again must not lose anything
+	g2, err := Parse(g.String())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if g.String() != g2.String() {
+		t.Errorf("round trip mismatch:\n%s\n%s", g.String(), g2.String())
+	}
+
+	for _, line := range strings.Split(g.Format(30), "\n") {
+		if len(line) > 30 && !strings.HasPrefix(line, "[") {
+			t.Errorf("line longer than 30 columns: %q", line)
+		}
+	}
+}
+
+func TestGame_Normalize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts NormalizeOptions
+		want string
+	}{
+		{
+			name: "strip clocks and drop tags",
+			opts: NormalizeOptions{
+				StripClocks: true,
+				DropTags:    []string{"ECO", "ECOUrl", "TimeControl", "Termination", "Site", "Date"},
+			},
+			want: `[Event "Live Chess"]
+[White "erik"]
+[Black "hikaru"]
+[Result "0-1"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bc4 $2 Bc5 0-1
+`,
+		},
+		{
+			name: "strip comments, rename, add and reorder tags",
+			opts: NormalizeOptions{
+				StripComments:       true,
+				DropTags:            []string{"ECOUrl", "TimeControl", "Termination", "Site", "Date"},
+				RenameTags:          map[string]string{"ECO": "Opening"},
+				AddTags:             []Tag{{Name: "Annotator", Value: "coach"}, {Name: "Event", Value: "Club \"match\""}},
+				SevenTagRosterFirst: true,
+				LineWidth:           20,
+			},
+			want: `[Event "Club \"match\""]
+[White "erik"]
+[Black "hikaru"]
+[Result "0-1"]
+[Opening "C50"]
+[Annotator "coach"]
+
+1. e4 e5 2. Nf3 Nc6
+3. Bc4 $2 Bc5 0-1
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := Parse(samplePGN)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.Normalize(tt.opts)
+			if got := g.Format(tt.opts.LineWidth); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTagPairs(t *testing.T) {
+	tags, err := ParseTagPairs("Annotator=coach, Team = Our club")
+	if err != nil || len(tags) != 2 || tags[1].Name != "Team" || tags[1].Value != "Our club" {
+		t.Errorf("ParseTagPairs() = %v, %v", tags, err)
+	}
+	if _, err := ParseTagPairs("Annotator"); err == nil {
+		t.Errorf("ParseTagPairs() expected an error")
+	}
+}
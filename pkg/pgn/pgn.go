@@ -0,0 +1,234 @@
+package pgn
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var clockRegexp = regexp.MustCompile(`\[%clk\s+(\d+):(\d+):(\d+(?:\.\d+)?)\]`)
+
+// Tag is a PGN tag pair such as [Event "Live Chess"]
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Move is a single half-move of the movetext with its annotations
+type Move struct {
+	Number  int
+	White   bool
+	SAN     string
+	NAGs    []string
+	Comment string
+}
+
+// Game is a parsed PGN game
+type Game struct {
+	Tags   []Tag
+	Moves  []Move
+	Result string
+}
+
+// Parse parses a single PGN game as returned by chess.com
+func Parse(s string) (*Game, error) {
+	g := &Game{}
+
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	idx := 0
+	for ; idx < len(lines); idx++ {
+		line := strings.TrimSpace(lines[idx])
+		if line == "" {
+			if len(g.Tags) > 0 {
+				break
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "[") {
+			break
+		}
+		tag, err := parseTag(line)
+		if err != nil {
+			return nil, err
+		}
+		g.Tags = append(g.Tags, tag)
+	}
+
+	if err := g.parseMovetext(strings.Join(lines[idx:], "\n")); err != nil {
+		return nil, err
+	}
+	if g.Result == "" {
+		g.Result = g.Tag("Result")
+	}
+
+	return g, nil
+}
+
+func parseTag(line string) (Tag, error) {
+	if !strings.HasSuffix(line, "]") {
+		return Tag{}, fmt.Errorf("malformed tag pair: %s", line)
+	}
+	line = strings.TrimSpace(line[1 : len(line)-1])
+	sep := strings.IndexAny(line, " \t")
+	if sep < 0 {
+		return Tag{}, fmt.Errorf("malformed tag pair: %s", line)
+	}
+	value, err := unquote(strings.TrimSpace(line[sep:]))
+	if err != nil {
+		return Tag{}, fmt.Errorf("malformed tag value: %s, err=%v", line, err)
+	}
+	return Tag{Name: line[:sep], Value: value}, nil
+}
+
+// unquote parses a PGN string token where only \\ and \" are escaped
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("missing quotes")
+	}
+	sb := strings.Builder{}
+	for i := 1; i < len(s)-1; i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s)-1 {
+			i++
+			c = s[i]
+		} else if c == '"' {
+			return "", fmt.Errorf("unescaped quote")
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), nil
+}
+
+func (g *Game) parseMovetext(text string) error {
+	number := 1
+	white := true
+	depth := 0 // variations nesting level, variations are skipped
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\n' || c == '\t' || c == '\r':
+			i++
+		case c == '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			comment := strings.TrimSpace(text[i+1 : i+end])
+			if depth == 0 && len(g.Moves) > 0 {
+				last := &g.Moves[len(g.Moves)-1]
+				if last.Comment != "" {
+					last.Comment += " "
+				}
+				last.Comment += comment
+			}
+			i += end + 1
+		case c == ';': // comment until end of line
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			i += end
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			if depth == 0 {
+				return fmt.Errorf("unexpected ')' at offset %d", i)
+			}
+			depth--
+			i++
+		default:
+			end := i
+			for end < len(text) && !strings.ContainsRune(" \n\t\r{}();", rune(text[end])) {
+				end++
+			}
+			token := text[i:end]
+			i = end
+			if depth > 0 {
+				continue
+			}
+
+			switch {
+			case token == "1-0" || token == "0-1" || token == "1/2-1/2" || token == "*":
+				g.Result = token
+			case token[0] == '$':
+				if len(g.Moves) > 0 {
+					last := &g.Moves[len(g.Moves)-1]
+					last.NAGs = append(last.NAGs, token)
+				}
+			case token[0] >= '0' && token[0] <= '9':
+				// Move number indication such as "12." or "12..."
+				n, err := strconv.Atoi(strings.TrimRight(token, "."))
+				if err != nil {
+					return fmt.Errorf("unexpected token %q", token)
+				}
+				number = n
+				white = !strings.HasSuffix(token, "...")
+			default:
+				g.Moves = append(g.Moves, Move{Number: number, White: white, SAN: token})
+				if !white {
+					number++
+				}
+				white = !white
+			}
+		}
+	}
+
+	if depth != 0 {
+		return fmt.Errorf("unterminated variation")
+	}
+	return nil
+}
+
+// Clock returns the remaining time on the player's clock after this move,
+// as given by chess.com's [%clk h:mm:ss.f] comment
+func (m Move) Clock() (time.Duration, bool) {
+	match := clockRegexp.FindStringSubmatch(m.Comment)
+	if match == nil {
+		return 0, false
+	}
+	hours, _ := strconv.Atoi(match[1])
+	minutes, _ := strconv.Atoi(match[2])
+	seconds, _ := strconv.ParseFloat(match[3], 64)
+	d := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds*float64(time.Second))
+	return d.Round(time.Millisecond), true
+}
+
+// TimeControl parses a live time control such as "180" or "180+2" (seconds) into the base time and increment,
+// ok is false for daily games ("1/259200") and missing or malformed time controls
+func TimeControl(tc string) (base, increment time.Duration, ok bool) {
+	parts := strings.SplitN(tc, "+", 2)
+	b, err := strconv.Atoi(parts[0])
+	if err != nil || b <= 0 {
+		return 0, 0, false
+	}
+	inc := 0
+	if len(parts) == 2 {
+		if inc, err = strconv.Atoi(parts[1]); err != nil || inc < 0 {
+			return 0, 0, false
+		}
+	}
+	return time.Duration(b) * time.Second, time.Duration(inc) * time.Second, true
+}
+
+// Tag returns the value of the given tag or an empty string if not present
+func (g *Game) Tag(name string) string {
+	for _, t := range g.Tags {
+		if t.Name == name {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// SANs returns all the moves of the game in standard algebraic notation
+func (g *Game) SANs() []string {
+	sans := make([]string, len(g.Moves))
+	for i, m := range g.Moves {
+		sans[i] = m.SAN
+	}
+	return sans
+}
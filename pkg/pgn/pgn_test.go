@@ -0,0 +1,146 @@
+package pgn
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const samplePGN = `[Event "Live Chess"]
+[Site "Chess.com"]
+[Date "2021.07.04"]
+[White "erik"]
+[Black "hikaru"]
+[Result "0-1"]
+[ECO "C50"]
+[ECOUrl "https://www.chess.com/openings/Italian-Game"]
+[TimeControl "180"]
+[Termination "hikaru won by resignation"]
+
+1. e4 {[%clk 0:02:59.9]} 1... e5 {[%clk 0:02:59.1]} 2. Nf3 {[%clk 0:02:58]} 2... Nc6 {[%clk 0:02:57.6]} 3. Bc4 $2 {[%clk 0:02:50]} (3. Bb5 a6) 3... Bc5 {[%clk 0:02:55]} 0-1
+`
+
+func TestParse(t *testing.T) {
+	g, err := Parse(samplePGN)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "tags", got: len(g.Tags), want: 10},
+		{name: "tag White", got: g.Tag("White"), want: "erik"},
+		{name: "tag missing", got: g.Tag("Missing"), want: ""},
+		{name: "result", got: g.Result, want: "0-1"},
+		{name: "moves", got: g.SANs(), want: []string{"e4", "e5", "Nf3", "Nc6", "Bc4", "Bc5"}},
+		{name: "comment", got: g.Moves[1].Comment, want: "[%clk 0:02:59.1]"},
+		{name: "nags", got: g.Moves[4].NAGs, want: []string{"$2"}},
+		{name: "black move number", got: g.Moves[5].Number, want: 3},
+		{name: "black move color", got: g.Moves[5].White, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		pgn  string
+	}{
+		{name: "malformed tag", pgn: "[Event Live]\n\n1. e4 *"},
+		{name: "unterminated comment", pgn: "1. e4 {comment"},
+		{name: "unterminated variation", pgn: "1. e4 (1. d4 *"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.pgn); err == nil {
+				t.Errorf("Parse() expected an error")
+			}
+		})
+	}
+}
+
+func TestMove_Clock(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment string
+		want    time.Duration
+		ok      bool
+	}{
+		{name: "tenths", comment: "[%clk 0:02:59.9]", want: 2*time.Minute + 59900*time.Millisecond, ok: true},
+		{name: "hours", comment: "[%clk 71:59:05]", want: 71*time.Hour + 59*time.Minute + 5*time.Second, ok: true},
+		{name: "mixed comment", comment: "good move [%clk 0:00:03]", want: 3 * time.Second, ok: true},
+		{name: "no clock", comment: "just a comment", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Move{Comment: tt.comment}.Clock()
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Clock() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTimeControl(t *testing.T) {
+	tests := []struct {
+		tc        string
+		base      time.Duration
+		increment time.Duration
+		ok        bool
+	}{
+		{tc: "180", base: 3 * time.Minute, ok: true},
+		{tc: "180+2", base: 3 * time.Minute, increment: 2 * time.Second, ok: true},
+		{tc: "1/259200", ok: false},
+		{tc: "", ok: false},
+		{tc: "-", ok: false},
+	}
+	for _, tt := range tests {
+		base, increment, ok := TimeControl(tt.tc)
+		if base != tt.base || increment != tt.increment || ok != tt.ok {
+			t.Errorf("TimeControl(%q) = %v, %v, %v, want %v, %v, %v", tt.tc, base, increment, ok, tt.base, tt.increment, tt.ok)
+		}
+	}
+}
+
+func TestGame_Positions(t *testing.T) {
+	tests := []struct {
+		name    string
+		pgn     string
+		want    string
+		wantErr bool
+	}{
+		{name: "standard", pgn: samplePGN, want: "r1bqk1nr/pppp1ppp/2n5/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4"},
+		{name: "setup", pgn: "[SetUp \"1\"]\n[FEN \"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1\"]\n\n1. e4 *", want: "4k3/8/8/8/4P3/8/8/4K3 b - e3 0 1"},
+		{name: "illegal move", pgn: "1. e5 *", wantErr: true},
+		{name: "variant", pgn: "[Variant \"Chess960\"]\n\n1. e4 *", wantErr: true},
+	}
+	for _, tt := range tests {
+		g, err := Parse(tt.pgn)
+		if err != nil {
+			t.Fatalf("%s: Parse() error = %v", tt.name, err)
+		}
+		positions, err := g.Positions()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Positions() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(positions) != len(g.Moves)+1 {
+			t.Errorf("%s: got %d positions, want %d", tt.name, len(positions), len(g.Moves)+1)
+		}
+		if got := positions[len(positions)-1].String(); got != tt.want {
+			t.Errorf("%s: final position = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package pgn
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Profile identifies the tool a PGN is written for
+type Profile string
+
+const (
+	// ProfileChesscom keeps games as returned by chess.com
+	ProfileChesscom Profile = "chesscom"
+	// ProfileLichess converts chess.com specific tags and annotations to the ones Lichess and most tools understand
+	ProfileLichess Profile = "lichess"
+)
+
+// Profiles lists all supported profiles
+var Profiles = []Profile{ProfileChesscom, ProfileLichess}
+
+// ParseProfile returns the profile named s, an empty string gives the chess.com profile
+func ParseProfile(s string) (Profile, error) {
+	if s == "" {
+		return ProfileChesscom, nil
+	}
+	for _, p := range Profiles {
+		if string(p) == strings.ToLower(s) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown PGN profile %q, expected one of %v", s, Profiles)
+}
+
+// chesscomOnlyTags are tags only chess.com makes use of, dropped by the Lichess profile
+var chesscomOnlyTags = []string{"CurrentPosition", "Timezone", "StartTime", "EndDate", "EndTime", "ECOUrl", "Link"}
+
+var clockTenthsRegexp = regexp.MustCompile(`(\[%clk\s+\d+:\d+:\d+)\.\d+\]`)
+
+// OpeningName converts a chess.com opening URL to a human readable opening name
+// e.g. https://www.chess.com/openings/Italian-Game-Two-Knights-Defense gives "Italian Game Two Knights Defense"
+func OpeningName(ecoURL string) string {
+	if ecoURL == "" {
+		return ""
+	}
+	return strings.ReplaceAll(path.Base(ecoURL), "-", " ")
+}
+
+// toLichess converts the game's tags and clocks the way Lichess exports its own games
+func (g *Game) toLichess() {
+	if link := g.Tag("Link"); link != "" {
+		g.SetTag("Site", link)
+	}
+	if opening := OpeningName(g.Tag("ECOUrl")); opening != "" && g.Tag("Opening") == "" {
+		g.SetTag("Opening", opening)
+	}
+	if tc := g.Tag("TimeControl"); tc != "" {
+		g.SetTag("TimeControl", LichessTimeControl(tc))
+	}
+	if g.Tag("Variant") == "" {
+		g.SetTag("Variant", "Standard")
+	}
+	if termination := g.Tag("Termination"); termination != "" {
+		g.SetTag("Termination", LichessTermination(termination))
+	}
+	for _, name := range chesscomOnlyTags {
+		g.RemoveTag(name)
+	}
+
+	for i := range g.Moves {
+		g.Moves[i].Comment = clockTenthsRegexp.ReplaceAllString(g.Moves[i].Comment, "$1]")
+	}
+}
+
+// LichessTimeControl converts a chess.com time control to the Lichess notation:
+// live games always get an increment ("180" gives "180+0") and daily games ("1/259200") give "-"
+func LichessTimeControl(tc string) string {
+	switch {
+	case strings.Contains(tc, "/"):
+		return "-"
+	case tc == "" || tc == "-" || strings.Contains(tc, "+"):
+		return tc
+	default:
+		return tc + "+0"
+	}
+}
+
+// LichessTermination converts a chess.com termination sentence (e.g. "Hikaru won on time")
+// to one of the Lichess terminations: Normal, Time forfeit or Abandoned
+func LichessTermination(termination string) string {
+	t := strings.ToLower(termination)
+	switch {
+	case strings.Contains(t, "abandoned"):
+		return "Abandoned"
+	case strings.Contains(t, " on time"), strings.Contains(t, "timeout"):
+		return "Time forfeit"
+	default:
+		return "Normal"
+	}
+}
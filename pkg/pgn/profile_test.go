@@ -0,0 +1,91 @@
+package pgn
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGame_Normalize_Lichess(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "lichess", "*.pgn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no test input found")
+	}
+
+	for _, input := range inputs {
+		input := input
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			data, err := ioutil.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g, err := Parse(string(data))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			opts := NormalizeOptions{Profile: ProfileLichess}
+			g.Normalize(opts)
+			got := g.FormatWith(opts.FormatOptions())
+
+			golden := strings.TrimSuffix(input, ".pgn") + ".golden"
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("Normalize() mismatch, got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestLichessTimeControl(t *testing.T) {
+	tests := []struct {
+		tc   string
+		want string
+	}{
+		{tc: "180", want: "180+0"},
+		{tc: "180+2", want: "180+2"},
+		{tc: "1/259200", want: "-"},
+		{tc: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := LichessTimeControl(tt.tc); got != tt.want {
+			t.Errorf("LichessTimeControl(%q) = %q, want %q", tt.tc, got, tt.want)
+		}
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Profile
+		wantErr bool
+	}{
+		{s: "", want: ProfileChesscom},
+		{s: "Lichess", want: ProfileLichess},
+		{s: "fics", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseProfile(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseProfile(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseProfile(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
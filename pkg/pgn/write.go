@@ -0,0 +1,92 @@
+package pgn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatOptions configures how a game is serialized
+type FormatOptions struct {
+	// LineWidth wraps the movetext at this number of columns, no wrapping if <= 0
+	LineWidth int
+	// PadComments adds a space after the opening and before the closing brace of comments (Lichess style)
+	PadComments bool
+}
+
+// String returns the game in PGN with the whole movetext on a single line, as chess.com does
+func (g *Game) String() string {
+	return g.Format(0)
+}
+
+// Format returns the game in PGN, wrapping the movetext at width columns (no wrapping if width <= 0)
+func (g *Game) Format(width int) string {
+	return g.FormatWith(FormatOptions{LineWidth: width})
+}
+
+// FormatWith returns the game in PGN formatted using opts
+func (g *Game) FormatWith(opts FormatOptions) string {
+	width := opts.LineWidth
+	sb := strings.Builder{}
+	for _, t := range g.Tags {
+		fmt.Fprintf(&sb, "[%s %s]\n", t.Name, quote(t.Value))
+	}
+	if len(g.Tags) > 0 {
+		sb.WriteString("\n")
+	}
+
+	line := 0
+	for _, token := range g.tokens(opts.PadComments) {
+		switch {
+		case line == 0:
+		case width > 0 && line+1+len(token) > width:
+			sb.WriteString("\n")
+			line = 0
+		default:
+			sb.WriteString(" ")
+			line++
+		}
+		sb.WriteString(token)
+		line += len(token)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// tokens returns the movetext as a list of tokens which cannot be split across lines
+func (g *Game) tokens(padComments bool) []string {
+	var tokens []string
+	needNumber := true // a black move needs its number after a comment or at the beginning
+	for _, m := range g.Moves {
+		switch {
+		case m.White:
+			tokens = append(tokens, strconv.Itoa(m.Number)+".")
+		case needNumber:
+			tokens = append(tokens, strconv.Itoa(m.Number)+"...")
+		}
+		tokens = append(tokens, m.SAN)
+		tokens = append(tokens, m.NAGs...)
+		needNumber = false
+		if m.Comment != "" {
+			comment := "{" + m.Comment + "}"
+			if padComments {
+				comment = "{ " + m.Comment + " }"
+			}
+			tokens = append(tokens, comment)
+			needNumber = true
+		}
+	}
+
+	result := g.Result
+	if result == "" {
+		result = "*"
+	}
+	return append(tokens, result)
+}
+
+// quote escapes a tag value as per the PGN specification
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
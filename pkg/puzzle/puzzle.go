@@ -0,0 +1,129 @@
+package puzzle
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/engine"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"github.com/notnil/chess"
+	"time"
+)
+
+// DefaultMinLoss is the default centipawn loss from which a move gives a puzzle
+const DefaultMinLoss = 200
+
+// Puzzle is a position in which the player lost at least the minimum centipawn loss,
+// the solution being the engine's best move and line
+type Puzzle struct {
+	GameURL string    `json:"game_url"`
+	Date    time.Time `json:"date"`
+	White   string    `json:"white"`
+	Black   string    `json:"black"`
+	// Color is the side to move, the player's color
+	Color string `json:"color"`
+	// Ply is the number of half-moves played before the position
+	Ply int    `json:"ply"`
+	FEN string `json:"fen"`
+	// Played is the move played in the game in SAN
+	Played string `json:"played"`
+	// Best is the engine's best move in SAN
+	Best string `json:"best"`
+	// Solution is the engine's principal variation in SAN, starting with the best move
+	Solution []string `json:"solution"`
+	// Before and After are the evaluations of the position before and after the move played, from the player's point of view
+	Before engine.Score `json:"-"`
+	After  engine.Score `json:"-"`
+	Loss   int          `json:"loss"`
+}
+
+// Extract analyzes username's game with pool and returns the positions in which username
+// lost at least minLoss centipawns. Variants are skipped.
+func Extract(game model.ChesscomGame, username string, pool *engine.Pool, minLoss int) ([]Puzzle, error) {
+	color := game.PlayerColor(username)
+	if color == "" || (game.Rules != "" && game.Rules != "chess") {
+		return nil, nil
+	}
+	g, err := pgn.Parse(game.PGN)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse PGN of game %s, err=%v", game.URL, err)
+	}
+	positions, moves, err := g.Replay()
+	if err != nil {
+		return nil, fmt.Errorf("unable to replay game %s, err=%v", game.URL, err)
+	}
+	evals, err := pool.Analyze(positions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to analyze game %s, err=%v", game.URL, err)
+	}
+
+	white := color == model.ColorWhite
+	var puzzles []Puzzle
+	for i, m := range g.Moves {
+		if m.White != white {
+			continue
+		}
+		before, after := evals[i], evals[i+1]
+		loss := engine.Loss(before.Score, after.Score, white)
+		if loss < minLoss || before.BestMove == "" {
+			continue
+		}
+		pos := positions[i]
+		if before.BestMove == (chess.UCINotation{}).Encode(pos, moves[i]) {
+			continue // the engine disagrees with itself at different depths
+		}
+		solution := solutionSAN(pos, before)
+		if len(solution) == 0 {
+			continue
+		}
+
+		p := Puzzle{
+			GameURL:  game.URL,
+			Date:     time.Unix(game.EndTime, 0).UTC(),
+			White:    game.White.Username,
+			Black:    game.Black.Username,
+			Color:    color,
+			Ply:      i,
+			FEN:      pos.String(),
+			Played:   m.SAN,
+			Best:     solution[0],
+			Solution: solution,
+			Before:   before.Score,
+			After:    after.Score,
+			Loss:     loss,
+		}
+		if !white {
+			p.Before, p.After = p.Before.Negate(), p.After.Negate()
+		}
+		puzzles = append(puzzles, p)
+	}
+	return puzzles, nil
+}
+
+// solutionSAN converts the engine's principal variation, or its best move if there is none, to SAN.
+// The conversion stops at the first illegal move.
+func solutionSAN(pos *chess.Position, eval engine.Eval) []string {
+	pv := eval.PV
+	if len(pv) == 0 || pv[0] != eval.BestMove {
+		pv = []string{eval.BestMove}
+	}
+	var sans []string
+	for _, uci := range pv {
+		m := legalMove(pos, uci)
+		if m == nil {
+			break
+		}
+		sans = append(sans, chess.AlgebraicNotation{}.Encode(pos, m))
+		pos = pos.Update(m)
+	}
+	return sans
+}
+
+// legalMove returns the legal move of pos given in UCI notation, nil if there is none
+func legalMove(pos *chess.Position, uci string) *chess.Move {
+	for _, m := range pos.ValidMoves() {
+		if (chess.UCINotation{}).Encode(pos, m) == uci {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package puzzle
+
+import (
+	"bytes"
+	"github.com/nmaupu/chesscom_exporter/pkg/engine"
+	"github.com/nmaupu/chesscom_exporter/pkg/engine/enginetest"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	enginetest.Main()
+	os.Exit(m.Run())
+}
+
+func TestExtract(t *testing.T) {
+	path, args := enginetest.Command()
+	pool, err := engine.NewPool(engine.Config{Path: path, Args: args, Depth: 1}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	game := model.ChesscomGame{
+		URL:     "https://www.chess.com/game/live/1",
+		PGN:     "[White \"erik\"]\n[Black \"hikaru\"]\n\n1. e4 d5 2. Qg4 Bxg4 0-1",
+		EndTime: 1625400000,
+		Rules:   "chess",
+		White:   model.ChesscomPlayerInfo{Username: "erik"},
+		Black:   model.ChesscomPlayerInfo{Username: "hikaru"},
+	}
+
+	tests := []struct {
+		username string
+		minLoss  int
+		want     int
+	}{
+		{username: "erik", minLoss: DefaultMinLoss, want: 1},
+		{username: "erik", minLoss: 1001, want: 0},
+		{username: "hikaru", minLoss: DefaultMinLoss, want: 0},
+		{username: "magnus", minLoss: 0, want: 0},
+	}
+	for _, tt := range tests {
+		puzzles, err := Extract(game, tt.username, pool, tt.minLoss)
+		if err != nil {
+			t.Fatalf("Extract(%s, %d) error = %v", tt.username, tt.minLoss, err)
+		}
+		if len(puzzles) != tt.want {
+			t.Errorf("Extract(%s, %d) = %d puzzles, want %d", tt.username, tt.minLoss, len(puzzles), tt.want)
+		}
+	}
+
+	puzzles, err := Extract(game, "erik", pool, DefaultMinLoss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := puzzles[0]
+	want := Puzzle{
+		GameURL:  game.URL,
+		Date:     p.Date,
+		White:    "erik",
+		Black:    "hikaru",
+		Color:    model.ColorWhite,
+		Ply:      2,
+		FEN:      "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2",
+		Played:   "Qg4",
+		Best:     "exd5",
+		Solution: []string{"exd5"},
+		Before:   engine.Score{CP: 100},
+		After:    engine.Score{CP: -900},
+		Loss:     1000,
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("Extract() = %+v, want %+v", p, want)
+	}
+
+	formats := []struct {
+		format Format
+		want   string
+	}{
+		{
+			format: FormatPGN,
+			want: "[Event \"Puzzle 1.2\"]\n[Site \"https://www.chess.com/game/live/1\"]\n[Date \"2021.07.04\"]\n[White \"erik\"]\n[Black \"hikaru\"]\n" +
+				"[Result \"*\"]\n[SetUp \"1\"]\n[FEN \"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2\"]\n\n" +
+				"2. exd5 {[%eval 1.00] played Qg4 [%eval -9.00], losing 1000 centipawns} *\n\n",
+		},
+		{
+			format: FormatEPD,
+			want: "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 bm exd5; am Qg4; ce 100; pv exd5; hmvc 0; fmvn 2;" +
+				" id \"1.2\"; c0 \"https://www.chess.com/game/live/1\";\n",
+		},
+	}
+	for _, tt := range formats {
+		buf := bytes.Buffer{}
+		if err := Write(&buf, p, tt.format); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("Write(%s) = %q, want %q", tt.format, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat(""); err != nil || f != FormatPGN {
+		t.Errorf("ParseFormat(\"\") = %v, %v", f, err)
+	}
+	if f, err := ParseFormat("EPD"); err != nil || f != FormatEPD {
+		t.Errorf("ParseFormat(EPD) = %v, %v", f, err)
+	}
+	if _, err := ParseFormat("csv"); err == nil {
+		t.Errorf("ParseFormat(csv) should fail")
+	}
+}
@@ -0,0 +1,105 @@
+package puzzle
+
+import (
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Format is an output format of puzzle sets
+type Format string
+
+const (
+	FormatPGN Format = "pgn"
+	FormatEPD Format = "epd"
+)
+
+// Formats lists all the available puzzle formats
+var Formats = []Format{FormatPGN, FormatEPD}
+
+// ParseFormat returns the Format corresponding to the given name, an empty name gives FormatPGN
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		return FormatPGN, nil
+	}
+	for _, f := range Formats {
+		if strings.EqualFold(string(f), name) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported puzzle format %q, expected one of %v", name, Formats)
+}
+
+// Write writes a puzzle to w in the given format
+func Write(w io.Writer, p Puzzle, format Format) error {
+	var s string
+	switch format {
+	case FormatPGN, "":
+		s = p.PGN() + "\n"
+	case FormatEPD:
+		s = p.EPD() + "\n"
+	default:
+		return fmt.Errorf("unsupported puzzle format %q", format)
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// id identifies the puzzle from its game and ply
+func (p Puzzle) id() string {
+	return fmt.Sprintf("%s.%d", path.Base(p.GameURL), p.Ply)
+}
+
+// PGN returns the puzzle as a PGN game starting from its position (SetUp and FEN tags)
+// with the solution as movetext, the move played and evaluations are given as comment
+func (p Puzzle) PGN() string {
+	fields := strings.Fields(p.FEN)
+	number, _ := strconv.Atoi(fields[5])
+	white := fields[1] == "w"
+
+	g := pgn.Game{
+		Tags: []pgn.Tag{
+			{Name: "Event", Value: "Puzzle " + p.id()},
+			{Name: "Site", Value: p.GameURL},
+			{Name: "Date", Value: p.Date.Format("2006.01.02")},
+			{Name: "White", Value: p.White},
+			{Name: "Black", Value: p.Black},
+			{Name: "Result", Value: "*"},
+			{Name: "SetUp", Value: "1"},
+			{Name: "FEN", Value: p.FEN},
+		},
+		Result: "*",
+	}
+	for _, san := range p.Solution {
+		g.Moves = append(g.Moves, pgn.Move{Number: number, White: white, SAN: san})
+		if !white {
+			number++
+		}
+		white = !white
+	}
+	g.Moves[0].Comment = fmt.Sprintf("[%%eval %s] played %s [%%eval %s], losing %d centipawns", p.Before, p.Played, p.After, p.Loss)
+	return g.String()
+}
+
+// EPD returns the puzzle as an EPD line: the best move is given by the bm opcode, the move played
+// by the am (avoid move) opcode and the evaluation in centipawns from the side to move by the ce opcode
+func (p Puzzle) EPD() string {
+	fields := strings.Fields(p.FEN)
+	sb := strings.Builder{}
+	sb.WriteString(strings.Join(fields[:4], " "))
+	fmt.Fprintf(&sb, " bm %s; am %s; ce %d;", p.Best, p.Played, p.Before.Centipawns())
+	if len(p.Solution) > 0 {
+		fmt.Fprintf(&sb, " pv %s;", strings.Join(p.Solution, " "))
+	}
+	fmt.Fprintf(&sb, " hmvc %s; fmvn %s;", fields[4], fields[5])
+	fmt.Fprintf(&sb, " id %s; c0 %s;", epdString(p.id()), epdString(p.GameURL))
+	return sb.String()
+}
+
+// epdString quotes an EPD string operand, which cannot contain double quotes
+func epdString(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
@@ -0,0 +1,170 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/exporter"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpponentRecord is the player's record against an opponent
+type OpponentRecord struct {
+	Opponent string `json:"opponent"`
+	Results
+	// Rating is the player's average rating in these games
+	Rating int `json:"rating"`
+	// RatingDifference is the average of the opponent's rating minus the player's rating
+	RatingDifference int                 `json:"rating_difference"`
+	FirstGame        time.Time           `json:"first_game"`
+	LastGame         time.Time           `json:"last_game"`
+	ByColor          map[string]*Results `json:"by_color"`
+	ByTimeClass      map[string]*Results `json:"by_time_class"`
+	Openings         []*OpeningResults   `json:"openings"`
+
+	ratings int
+}
+
+func (o *OpponentRecord) add(rec *exporter.Record) {
+	o.Results.add(rec)
+	o.ratings += rec.Rating
+	o.Rating = o.ratings / o.Games
+	o.RatingDifference = o.OpponentRating - o.Rating
+	if o.FirstGame.IsZero() || rec.EndTime.Before(o.FirstGame) {
+		o.FirstGame = rec.EndTime
+	}
+	if rec.EndTime.After(o.LastGame) {
+		o.LastGame = rec.EndTime
+	}
+	results(o.ByColor, rec.Color).add(rec)
+	results(o.ByTimeClass, rec.TimeClass).add(rec)
+	o.opening(rec).add(rec)
+}
+
+func (o *OpponentRecord) opening(rec *exporter.Record) *Results {
+	name := rec.OpeningFamily
+	if name == "" {
+		name = "Unknown"
+	}
+	for _, op := range o.Openings {
+		if op.Color == rec.Color && op.Opening == name {
+			return &op.Results
+		}
+	}
+	op := &OpeningResults{Color: rec.Color, ECO: rec.ECO, Opening: name}
+	o.Openings = append(o.Openings, op)
+	return &op.Results
+}
+
+// HeadToHead is the player's record against each of their opponents
+type HeadToHead struct {
+	Username  string            `json:"username"`
+	Opponents []*OpponentRecord `json:"opponents"`
+
+	byName map[string]*OpponentRecord
+	top    int
+}
+
+// NewHeadToHead returns an empty head-to-head report for username, top is the number
+// of opponents and of openings per opponent rendered in tables (DefaultTop if <= 0)
+func NewHeadToHead(username string, top int) *HeadToHead {
+	if top <= 0 {
+		top = DefaultTop
+	}
+	return &HeadToHead{Username: username, byName: make(map[string]*OpponentRecord), top: top}
+}
+
+// Add aggregates a game, it returns false if the player did not take part in it
+func (h *HeadToHead) Add(game model.ChesscomGame) (bool, error) {
+	if game.PlayerColor(h.Username) == "" {
+		return false, nil
+	}
+	rec, err := exporter.NewRecord(game, h.Username)
+	if err != nil {
+		return false, err
+	}
+
+	// chess.com usernames are case insensitive
+	key := strings.ToLower(rec.Opponent)
+	o, ok := h.byName[key]
+	if !ok {
+		o = &OpponentRecord{
+			Opponent:    rec.Opponent,
+			ByColor:     make(map[string]*Results),
+			ByTimeClass: make(map[string]*Results),
+		}
+		h.byName[key] = o
+		h.Opponents = append(h.Opponents, o)
+	}
+	o.add(rec)
+	return true, nil
+}
+
+// sort orders opponents and their openings by number of games
+func (h *HeadToHead) sort() {
+	sort.SliceStable(h.Opponents, func(i, j int) bool { return h.Opponents[i].Games > h.Opponents[j].Games })
+	for _, o := range h.Opponents {
+		openings := o.Openings
+		sort.SliceStable(openings, func(i, j int) bool { return openings[i].Games > openings[j].Games })
+	}
+}
+
+// MarshalJSON sorts the opponents before they are encoded
+func (h *HeadToHead) MarshalJSON() ([]byte, error) {
+	h.sort()
+	type headToHead HeadToHead
+	return json.Marshal((*headToHead)(h))
+}
+
+// Title returns the title of the report
+func (h *HeadToHead) Title() string {
+	if len(h.Opponents) == 1 {
+		return fmt.Sprintf("%s vs %s", h.Username, h.Opponents[0].Opponent)
+	}
+	return fmt.Sprintf("Head-to-head records of %s", h.Username)
+}
+
+// Tables returns the most played opponents, then the results by color and time class
+// and the openings played against each of them
+func (h *HeadToHead) Tables() []Table {
+	h.sort()
+	opponents := h.Opponents
+	if len(opponents) > h.top {
+		opponents = opponents[:h.top]
+	}
+
+	summary := Table{
+		Title:   "Opponents",
+		Columns: append(append([]string{"Opponent"}, resultsColumns...), "Rating", "Diff.", "First game", "Last game"),
+	}
+	details := Table{Title: "By color and time class", Columns: append([]string{"Opponent", ""}, resultsColumns...)}
+	openings := Table{Title: "Openings", Columns: append([]string{"Opponent", "Color", "ECO", "Opening"}, resultsColumns...)}
+	for _, o := range opponents {
+		row := append([]string{o.Opponent}, o.cells()...)
+		summary.Rows = append(summary.Rows, append(row,
+			strconv.Itoa(o.Rating), fmt.Sprintf("%+d", o.RatingDifference),
+			o.FirstGame.Format("2006-01-02"), o.LastGame.Format("2006-01-02"),
+		))
+
+		for _, color := range []string{model.ColorWhite, model.ColorBlack} {
+			if r, ok := o.ByColor[color]; ok {
+				details.Rows = append(details.Rows, append([]string{o.Opponent, "As " + color}, r.cells()...))
+			}
+		}
+		for _, tc := range sortedKeys(o.ByTimeClass) {
+			details.Rows = append(details.Rows, append([]string{o.Opponent, tc}, o.ByTimeClass[tc].cells()...))
+		}
+
+		for i, op := range o.Openings {
+			if i >= h.top {
+				break
+			}
+			openings.Rows = append(openings.Rows, append([]string{o.Opponent, op.Color, op.ECO, op.Opening}, op.cells()...))
+		}
+	}
+
+	return []Table{summary, details, openings}
+}
@@ -0,0 +1,60 @@
+package report
+
+import (
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"testing"
+)
+
+func TestHeadToHead(t *testing.T) {
+	games := append(sampleGames(),
+		game("Alice", "erik", 1620, 1500, "checkmated", "win", "blitz", "1. d4 d5"),
+		game("erik", "alice", 1510, 1610, "agreed", "agreed", "rapid", "1. e4 c5"),
+	)
+	h := NewHeadToHead("erik", 2)
+	for i, g := range games {
+		added, err := h.Add(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if added != (i != 4) {
+			t.Errorf("Add() game %d = %v", i, added)
+		}
+	}
+
+	tables := h.Tables()
+	alice := h.Opponents[0]
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "opponents", got: len(h.Opponents), want: 4},
+		{name: "opponent", got: alice.Opponent, want: "alice"},
+		{name: "games", got: alice.Games, want: 3},
+		{name: "wins", got: alice.Wins, want: 2},
+		{name: "draws", got: alice.Draws, want: 1},
+		{name: "score", got: alice.Score, want: 83.3},
+		{name: "rating", got: alice.Rating, want: (1490 + 1500 + 1510) / 3},
+		{name: "rating difference", got: alice.RatingDifference, want: (1600+1620+1610)/3 - (1490+1500+1510)/3},
+		{name: "black games", got: alice.ByColor[model.ColorBlack].Games, want: 2},
+		{name: "blitz games", got: alice.ByTimeClass["blitz"].Games, want: 1},
+		{name: "top opening", got: alice.Openings[0].Opening, want: "Queen's Pawn Game"},
+		{name: "listed opponents", got: len(tables[0].Rows), want: 2},
+		{name: "title", got: h.Title(), want: "Head-to-head records of erik"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestHeadToHead_Title(t *testing.T) {
+	h := NewHeadToHead("erik", 0)
+	if _, err := h.Add(game("erik", "hikaru", 1500, 3200, "win", "resigned", "blitz", "1. e4 c5")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h.Title(), "erik vs hikaru"; got != want {
+		t.Errorf("Title() = %q, want %q", got, want)
+	}
+}
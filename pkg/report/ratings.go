@@ -0,0 +1,237 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sampling is the period ratings are downsampled to
+type Sampling string
+
+const (
+	SamplingGame Sampling = "game"
+	SamplingDay  Sampling = "day"
+	SamplingWeek Sampling = "week"
+)
+
+// Samplings lists all the available samplings
+var Samplings = []Sampling{SamplingGame, SamplingDay, SamplingWeek}
+
+// ParseSampling returns the Sampling corresponding to the given name, an empty name gives SamplingDay
+func ParseSampling(name string) (Sampling, error) {
+	if name == "" {
+		return SamplingDay, nil
+	}
+	for _, s := range Samplings {
+		if strings.EqualFold(string(s), name) {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported sampling %q, expected one of %v", name, Samplings)
+}
+
+// start returns the beginning of the period t belongs to, weeks start on Monday
+func (s Sampling) start(t time.Time) time.Time {
+	switch s {
+	case SamplingDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case SamplingWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return t
+}
+
+// RatingPoint is the player's rating at the end of a game or of a period
+type RatingPoint struct {
+	// Time is the end of the game or the beginning of the period
+	Time time.Time `json:"time"`
+	// Rating is the rating after the last game of the period
+	Rating int `json:"rating"`
+	Min    int `json:"min"`
+	Max    int `json:"max"`
+	Games  int `json:"games"`
+}
+
+// RatingExtreme is a rating reached at a given time
+type RatingExtreme struct {
+	Rating int       `json:"rating"`
+	Time   time.Time `json:"time"`
+}
+
+// Drawdown is a rating loss from a peak to the lowest rating reached before that peak is exceeded
+type Drawdown struct {
+	Points int           `json:"points"`
+	Peak   RatingExtreme `json:"peak"`
+	Trough RatingExtreme `json:"trough"`
+}
+
+// RatingSeries is the rating history of a time class
+type RatingSeries struct {
+	TimeClass   string        `json:"time_class"`
+	Games       int           `json:"games"`
+	First       int           `json:"first"`
+	Last        int           `json:"last"`
+	Change      int           `json:"change"`
+	Peak        RatingExtreme `json:"peak"`
+	Low         RatingExtreme `json:"low"`
+	MaxDrawdown Drawdown      `json:"max_drawdown"`
+	Points      []RatingPoint `json:"points"`
+}
+
+// RatingHistory is a player's rating history per time class
+type RatingHistory struct {
+	Username string          `json:"username"`
+	Sampling Sampling        `json:"sampling"`
+	Series   []*RatingSeries `json:"series"`
+
+	games map[string][]RatingPoint
+}
+
+// NewRatingHistory returns an empty rating history of username downsampled with sampling
+func NewRatingHistory(username string, sampling Sampling) *RatingHistory {
+	return &RatingHistory{Username: username, Sampling: sampling, games: make(map[string][]RatingPoint)}
+}
+
+// Add records the player's rating after a game, it returns false if the player did not take part in it
+func (h *RatingHistory) Add(game model.ChesscomGame) bool {
+	color := game.PlayerColor(h.Username)
+	if color == "" {
+		return false
+	}
+	rating := game.Player(color).Rating
+	h.games[game.TimeClass] = append(h.games[game.TimeClass], RatingPoint{
+		Time:   time.Unix(game.EndTime, 0).UTC(),
+		Rating: rating,
+		Min:    rating,
+		Max:    rating,
+		Games:  1,
+	})
+	return true
+}
+
+// compute builds the series from the games added so far
+func (h *RatingHistory) compute() {
+	h.Series = nil
+	for timeClass, games := range h.games {
+		sort.SliceStable(games, func(i, j int) bool { return games[i].Time.Before(games[j].Time) })
+		s := &RatingSeries{
+			TimeClass: timeClass,
+			Games:     len(games),
+			First:     games[0].Rating,
+			Last:      games[len(games)-1].Rating,
+			Peak:      RatingExtreme{Rating: games[0].Rating, Time: games[0].Time},
+			Low:       RatingExtreme{Rating: games[0].Rating, Time: games[0].Time},
+		}
+		s.Change = s.Last - s.First
+
+		peak := s.Peak
+		for _, g := range games {
+			current := RatingExtreme{Rating: g.Rating, Time: g.Time}
+			if g.Rating > s.Peak.Rating {
+				s.Peak = current
+			}
+			if g.Rating < s.Low.Rating {
+				s.Low = current
+			}
+			if g.Rating > peak.Rating {
+				peak = current
+			}
+			if peak.Rating-g.Rating > s.MaxDrawdown.Points {
+				s.MaxDrawdown = Drawdown{Points: peak.Rating - g.Rating, Peak: peak, Trough: current}
+			}
+
+			start := h.Sampling.start(g.Time)
+			last := len(s.Points) - 1
+			if h.Sampling == SamplingGame || last < 0 || !s.Points[last].Time.Equal(start) {
+				s.Points = append(s.Points, RatingPoint{Time: start, Rating: g.Rating, Min: g.Rating, Max: g.Rating, Games: 1})
+				continue
+			}
+			p := &s.Points[last]
+			p.Rating = g.Rating
+			p.Games++
+			if g.Rating < p.Min {
+				p.Min = g.Rating
+			}
+			if g.Rating > p.Max {
+				p.Max = g.Rating
+			}
+		}
+		h.Series = append(h.Series, s)
+	}
+	sort.Slice(h.Series, func(i, j int) bool { return h.Series[i].TimeClass < h.Series[j].TimeClass })
+}
+
+// MarshalJSON computes the series before they are encoded
+func (h *RatingHistory) MarshalJSON() ([]byte, error) {
+	h.compute()
+	type history RatingHistory
+	return json.Marshal((*history)(h))
+}
+
+// Title returns the title of the report
+func (h *RatingHistory) Title() string {
+	return fmt.Sprintf("Rating history of %s", h.Username)
+}
+
+// Tables returns a summary of each time class followed by their series
+func (h *RatingHistory) Tables() []Table {
+	h.compute()
+
+	summary := Table{
+		Title:   "Summary",
+		Columns: []string{"Time class", "Games", "First", "Last", "Change", "Peak", "Peak date", "Lowest", "Max drawdown", "Drawdown period"},
+	}
+	tables := []Table{summary}
+	for _, s := range h.Series {
+		drawdown := "-"
+		if s.MaxDrawdown.Points > 0 {
+			drawdown = s.MaxDrawdown.Peak.Time.Format("2006-01-02") + " to " + s.MaxDrawdown.Trough.Time.Format("2006-01-02")
+		}
+		tables[0].Rows = append(tables[0].Rows, []string{
+			s.TimeClass, strconv.Itoa(s.Games), strconv.Itoa(s.First), strconv.Itoa(s.Last), fmt.Sprintf("%+d", s.Change),
+			strconv.Itoa(s.Peak.Rating), s.Peak.Time.Format("2006-01-02"), strconv.Itoa(s.Low.Rating),
+			strconv.Itoa(s.MaxDrawdown.Points), drawdown,
+		})
+
+		t := Table{Title: fmt.Sprintf("%s (per %s)", s.TimeClass, h.Sampling), Columns: []string{"Date", "Rating", "Min", "Max", "Games"}}
+		for _, p := range s.Points {
+			t.Rows = append(t.Rows, []string{h.formatTime(p.Time), strconv.Itoa(p.Rating), strconv.Itoa(p.Min), strconv.Itoa(p.Max), strconv.Itoa(p.Games)})
+		}
+		tables = append(tables, t)
+	}
+	return tables
+}
+
+func (h *RatingHistory) formatTime(t time.Time) string {
+	if h.Sampling == SamplingGame {
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return t.Format("2006-01-02")
+}
+
+// WriteCSV writes all the series as CSV, one row per point
+func (h *RatingHistory) WriteCSV(w io.Writer) error {
+	h.compute()
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time_class", "date", "rating", "min", "max", "games"}); err != nil {
+		return err
+	}
+	for _, s := range h.Series {
+		for _, p := range s.Points {
+			err := cw.Write([]string{s.TimeClass, h.formatTime(p.Time), strconv.Itoa(p.Rating), strconv.Itoa(p.Min), strconv.Itoa(p.Max), strconv.Itoa(p.Games)})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,96 @@
+package report
+
+import (
+	"bytes"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ratedGame(timeClass string, end time.Time, rating int) model.ChesscomGame {
+	return model.ChesscomGame{
+		EndTime:   end.Unix(),
+		TimeClass: timeClass,
+		White:     model.ChesscomPlayerInfo{Username: "erik", Rating: rating},
+		Black:     model.ChesscomPlayerInfo{Username: "hikaru", Rating: 3200},
+	}
+}
+
+func TestRatingHistory(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2021, 7, d, h, 0, 0, 0, time.UTC) }
+	games := []model.ChesscomGame{
+		ratedGame("blitz", day(5, 10), 1500), // Monday
+		ratedGame("blitz", day(5, 12), 1550),
+		ratedGame("blitz", day(7, 9), 1480),
+		ratedGame("blitz", day(6, 9), 1600), // out of order, sorted by time
+		ratedGame("blitz", day(12, 9), 1520),
+		ratedGame("rapid", day(5, 9), 1700),
+	}
+
+	tests := []struct {
+		sampling Sampling
+		points   []RatingPoint
+	}{
+		{
+			sampling: SamplingDay,
+			points: []RatingPoint{
+				{Time: day(5, 0), Rating: 1550, Min: 1500, Max: 1550, Games: 2},
+				{Time: day(6, 0), Rating: 1600, Min: 1600, Max: 1600, Games: 1},
+				{Time: day(7, 0), Rating: 1480, Min: 1480, Max: 1480, Games: 1},
+				{Time: day(12, 0), Rating: 1520, Min: 1520, Max: 1520, Games: 1},
+			},
+		},
+		{
+			sampling: SamplingWeek,
+			points: []RatingPoint{
+				{Time: day(5, 0), Rating: 1480, Min: 1480, Max: 1600, Games: 4},
+				{Time: day(12, 0), Rating: 1520, Min: 1520, Max: 1520, Games: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		h := NewRatingHistory("erik", tt.sampling)
+		for _, g := range games {
+			h.Add(g)
+		}
+		h.compute()
+		if len(h.Series) != 2 || h.Series[0].TimeClass != "blitz" {
+			t.Fatalf("%s: unexpected series %+v", tt.sampling, h.Series)
+		}
+		blitz := h.Series[0]
+		if len(blitz.Points) != len(tt.points) {
+			t.Fatalf("%s: got %d points, want %d", tt.sampling, len(blitz.Points), len(tt.points))
+		}
+		for i, p := range blitz.Points {
+			want := tt.points[i]
+			if !p.Time.Equal(want.Time) || p.Rating != want.Rating || p.Min != want.Min || p.Max != want.Max || p.Games != want.Games {
+				t.Errorf("%s: point %d = %+v, want %+v", tt.sampling, i, p, want)
+			}
+		}
+
+		if blitz.First != 1500 || blitz.Last != 1520 || blitz.Change != 20 || blitz.Peak.Rating != 1600 || blitz.Low.Rating != 1480 {
+			t.Errorf("%s: unexpected summary %+v", tt.sampling, blitz)
+		}
+		if dd := blitz.MaxDrawdown; dd.Points != 120 || !dd.Peak.Time.Equal(day(6, 9)) || !dd.Trough.Time.Equal(day(7, 9)) {
+			t.Errorf("%s: unexpected drawdown %+v", tt.sampling, dd)
+		}
+	}
+
+	h := NewRatingHistory("erik", SamplingWeek)
+	for _, g := range games {
+		h.Add(g)
+	}
+	buf := bytes.Buffer{}
+	if err := Write(&buf, h, FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	want := "time_class,date,rating,min,max,games\nblitz,2021-07-05,1480,1480,1600,4\nblitz,2021-07-12,1520,1520,1520,1\nrapid,2021-07-05,1700,1700,1700,1\n"
+	if buf.String() != want {
+		t.Errorf("WriteCSV() = %q, want %q", buf.String(), want)
+	}
+
+	if err := Write(&buf, NewStats("erik", 0), FormatCSV); err == nil || !strings.Contains(err.Error(), "CSV") {
+		t.Errorf("Write() expected an error writing statistics as CSV, got %v", err)
+	}
+}
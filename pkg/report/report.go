@@ -0,0 +1,134 @@
+// Package report computes reports from chess.com games and renders them as text tables, JSON or Markdown
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format is an output format of reports
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatJSON     Format = "json"
+	FormatMarkdown Format = "markdown"
+	// FormatCSV is only available for reports implementing CSVReport
+	FormatCSV Format = "csv"
+)
+
+// Formats lists all the available report formats
+var Formats = []Format{FormatText, FormatJSON, FormatMarkdown, FormatCSV}
+
+// ParseFormat returns the Format corresponding to the given name, an empty name gives FormatText
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		return FormatText, nil
+	}
+	if strings.EqualFold(name, "md") {
+		return FormatMarkdown, nil
+	}
+	for _, f := range Formats {
+		if strings.EqualFold(string(f), name) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported report format %q", name)
+}
+
+// Report is a report which can be rendered as tables, it is rendered as is in JSON
+type Report interface {
+	Title() string
+	Tables() []Table
+}
+
+// CSVReport is a report which can also be written as CSV
+type CSVReport interface {
+	Report
+	WriteCSV(w io.Writer) error
+}
+
+// Table is a titled table of a report
+type Table struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+}
+
+// Write renders the report to w in the given format
+func Write(w io.Writer, r Report, format Format) error {
+	switch format {
+	case FormatText, "":
+		return WriteText(w, r)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatMarkdown:
+		return WriteMarkdown(w, r)
+	case FormatCSV:
+		if cr, ok := r.(CSVReport); ok {
+			return cr.WriteCSV(w)
+		}
+		return fmt.Errorf("%s cannot be written as CSV", strings.ToLower(r.Title()))
+	}
+	return fmt.Errorf("unsupported report format %q", format)
+}
+
+// WriteText renders the report as aligned text tables, suitable for a terminal
+func WriteText(w io.Writer, r Report) error {
+	if _, err := fmt.Fprintf(w, "%s\n%s\n", r.Title(), strings.Repeat("=", len(r.Title()))); err != nil {
+		return err
+	}
+	for _, t := range r.Tables() {
+		if _, err := fmt.Fprintf(w, "\n%s\n", t.Title); err != nil {
+			return err
+		}
+		if len(t.Rows) == 0 {
+			if _, err := fmt.Fprintln(w, "  (none)"); err != nil {
+				return err
+			}
+			continue
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "  %s\n", strings.Join(t.Columns, "\t"))
+		for _, row := range t.Rows {
+			fmt.Fprintf(tw, "  %s\n", strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteMarkdown renders the report as Markdown tables
+func WriteMarkdown(w io.Writer, r Report) error {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "# %s\n", r.Title())
+	for _, t := range r.Tables() {
+		fmt.Fprintf(&sb, "\n## %s\n\n", t.Title)
+		if len(t.Rows) == 0 {
+			sb.WriteString("_None_\n")
+			continue
+		}
+		sb.WriteString("| " + strings.Join(escapeMarkdown(t.Columns), " | ") + " |\n")
+		sb.WriteString("|" + strings.Repeat(" --- |", len(t.Columns)) + "\n")
+		for _, row := range t.Rows {
+			sb.WriteString("| " + strings.Join(escapeMarkdown(row), " | ") + " |\n")
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func escapeMarkdown(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		escaped[i] = strings.ReplaceAll(c, "|", `\|`)
+	}
+	return escaped
+}
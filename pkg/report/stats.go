@@ -0,0 +1,289 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/exporter"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultTop is the default number of openings and best wins listed in tables
+const DefaultTop = 10
+
+// Results aggregates the results of a set of games from the player's perspective
+type Results struct {
+	Games  int `json:"games"`
+	Wins   int `json:"wins"`
+	Draws  int `json:"draws"`
+	Losses int `json:"losses"`
+	// Score is the percentage of points scored, 1 for a win and 0.5 for a draw
+	Score float64 `json:"score"`
+	// OpponentRating is the average rating of the opponents
+	OpponentRating int `json:"opponent_rating"`
+	// Performance is the rating performance computed as OpponentRating + 400 * (Wins - Losses) / Games
+	Performance int `json:"performance"`
+	// AccuracyGames is the number of games with an accuracy, only those are used for accuracy averages
+	AccuracyGames    int     `json:"accuracy_games"`
+	Accuracy         float64 `json:"accuracy,omitempty"`
+	OpponentAccuracy float64 `json:"opponent_accuracy,omitempty"`
+
+	opponentRatings    int
+	accuracies         float64
+	opponentAccuracies float64
+}
+
+func (r *Results) add(rec *exporter.Record) {
+	r.Games++
+	switch rec.Result {
+	case model.OutcomeWin:
+		r.Wins++
+	case model.OutcomeDraw:
+		r.Draws++
+	default:
+		r.Losses++
+	}
+	r.opponentRatings += rec.OpponentRating
+	// Games not analyzed by chess.com have no accuracy, they are not counted as 0
+	if rec.Accuracy > 0 {
+		r.AccuracyGames++
+		r.accuracies += rec.Accuracy
+		r.opponentAccuracies += rec.OpponentAccuracy
+		r.Accuracy = round(r.accuracies/float64(r.AccuracyGames), 1)
+		r.OpponentAccuracy = round(r.opponentAccuracies/float64(r.AccuracyGames), 1)
+	}
+
+	r.Score = round(100*(float64(r.Wins)+float64(r.Draws)/2)/float64(r.Games), 1)
+	r.OpponentRating = r.opponentRatings / r.Games
+	r.Performance = r.OpponentRating + 400*(r.Wins-r.Losses)/r.Games
+}
+
+func (r *Results) cells() []string {
+	accuracy := "-"
+	if r.AccuracyGames > 0 {
+		accuracy = fmt.Sprintf("%.1f (%d)", r.Accuracy, r.AccuracyGames)
+	}
+	return []string{
+		strconv.Itoa(r.Games), strconv.Itoa(r.Wins), strconv.Itoa(r.Draws), strconv.Itoa(r.Losses),
+		fmt.Sprintf("%.1f%%", r.Score), strconv.Itoa(r.OpponentRating), strconv.Itoa(r.Performance), accuracy,
+	}
+}
+
+var resultsColumns = []string{"Games", "W", "D", "L", "Score", "Avg opp.", "Perf.", "Accuracy"}
+
+// OpeningResults are the results obtained with an opening and a color
+type OpeningResults struct {
+	Color   string `json:"color"`
+	ECO     string `json:"eco"`
+	Opening string `json:"opening"`
+	Results
+}
+
+// TerminationResults counts how games ended, from chess.com's result codes (resigned, timeout, checkmated, agreed, etc.):
+// wins are counted with the opponent's code, draws and losses with the player's code
+type TerminationResults struct {
+	Termination string `json:"termination"`
+	Wins        int    `json:"wins"`
+	Draws       int    `json:"draws"`
+	Losses      int    `json:"losses"`
+}
+
+// Win is a game won by the player
+type Win struct {
+	Date           time.Time `json:"date"`
+	TimeClass      string    `json:"time_class"`
+	Color          string    `json:"color"`
+	Rating         int       `json:"rating"`
+	Opponent       string    `json:"opponent"`
+	OpponentRating int       `json:"opponent_rating"`
+	Termination    string    `json:"termination"`
+	URL            string    `json:"url"`
+}
+
+// Stats are a player's aggregated statistics
+type Stats struct {
+	Username     string                `json:"username"`
+	Overall      Results               `json:"overall"`
+	ByColor      map[string]*Results   `json:"by_color"`
+	ByTimeClass  map[string]*Results   `json:"by_time_class"`
+	Openings     []*OpeningResults     `json:"openings"`
+	Terminations []*TerminationResults `json:"terminations"`
+	BestWins     []Win                 `json:"best_wins"`
+
+	top int
+}
+
+// NewStats returns empty statistics for username, top is the number of openings
+// and best wins rendered in tables (DefaultTop if <= 0)
+func NewStats(username string, top int) *Stats {
+	if top <= 0 {
+		top = DefaultTop
+	}
+	return &Stats{
+		Username:    username,
+		ByColor:     make(map[string]*Results),
+		ByTimeClass: make(map[string]*Results),
+		top:         top,
+	}
+}
+
+// Add aggregates a game, it returns false if the player did not take part in it
+func (s *Stats) Add(game model.ChesscomGame) (bool, error) {
+	if game.PlayerColor(s.Username) == "" {
+		return false, nil
+	}
+	rec, err := exporter.NewRecord(game, s.Username)
+	if err != nil {
+		return false, err
+	}
+
+	s.Overall.add(rec)
+	results(s.ByColor, rec.Color).add(rec)
+	results(s.ByTimeClass, rec.TimeClass).add(rec)
+	s.opening(rec).add(rec)
+	s.termination(rec)
+	if rec.Result == model.OutcomeWin {
+		s.BestWins = append(s.BestWins, Win{
+			Date:           rec.EndTime,
+			TimeClass:      rec.TimeClass,
+			Color:          rec.Color,
+			Rating:         rec.Rating,
+			Opponent:       rec.Opponent,
+			OpponentRating: rec.OpponentRating,
+			Termination:    rec.OpponentCode,
+			URL:            rec.URL,
+		})
+	}
+	return true, nil
+}
+
+func results(m map[string]*Results, key string) *Results {
+	r, ok := m[key]
+	if !ok {
+		r = &Results{}
+		m[key] = r
+	}
+	return r
+}
+
+func (s *Stats) opening(rec *exporter.Record) *Results {
+	name := rec.OpeningFamily
+	if name == "" {
+		name = "Unknown"
+	}
+	for _, o := range s.Openings {
+		if o.Color == rec.Color && o.Opening == name {
+			return &o.Results
+		}
+	}
+	o := &OpeningResults{Color: rec.Color, ECO: rec.ECO, Opening: name}
+	s.Openings = append(s.Openings, o)
+	return &o.Results
+}
+
+func (s *Stats) termination(rec *exporter.Record) {
+	code := rec.ResultCode
+	if rec.Result == model.OutcomeWin {
+		code = rec.OpponentCode
+	}
+	var t *TerminationResults
+	for _, tr := range s.Terminations {
+		if tr.Termination == code {
+			t = tr
+		}
+	}
+	if t == nil {
+		t = &TerminationResults{Termination: code}
+		s.Terminations = append(s.Terminations, t)
+	}
+	switch rec.Result {
+	case model.OutcomeWin:
+		t.Wins++
+	case model.OutcomeDraw:
+		t.Draws++
+	default:
+		t.Losses++
+	}
+}
+
+// sort orders openings by number of games, terminations by number of games and wins by opponent rating
+func (s *Stats) sort() {
+	sort.SliceStable(s.Openings, func(i, j int) bool { return s.Openings[i].Games > s.Openings[j].Games })
+	sort.SliceStable(s.Terminations, func(i, j int) bool {
+		ti, tj := s.Terminations[i], s.Terminations[j]
+		return ti.Wins+ti.Draws+ti.Losses > tj.Wins+tj.Draws+tj.Losses
+	})
+	sort.SliceStable(s.BestWins, func(i, j int) bool { return s.BestWins[i].OpponentRating > s.BestWins[j].OpponentRating })
+}
+
+// MarshalJSON sorts the statistics before they are encoded
+func (s *Stats) MarshalJSON() ([]byte, error) {
+	s.sort()
+	type stats Stats
+	return json.Marshal((*stats)(s))
+}
+
+// Title returns the title of the report
+func (s *Stats) Title() string {
+	return fmt.Sprintf("Statistics of %s", s.Username)
+}
+
+// Tables returns the statistics as tables
+func (s *Stats) Tables() []Table {
+	s.sort()
+
+	overall := Table{Title: "Overall", Columns: append([]string{""}, resultsColumns...)}
+	overall.Rows = append(overall.Rows, append([]string{"All games"}, s.Overall.cells()...))
+	for _, color := range []string{model.ColorWhite, model.ColorBlack} {
+		if r, ok := s.ByColor[color]; ok {
+			overall.Rows = append(overall.Rows, append([]string{"As " + color}, r.cells()...))
+		}
+	}
+
+	timeClasses := Table{Title: "By time class", Columns: append([]string{"Time class"}, resultsColumns...)}
+	for _, tc := range sortedKeys(s.ByTimeClass) {
+		timeClasses.Rows = append(timeClasses.Rows, append([]string{tc}, s.ByTimeClass[tc].cells()...))
+	}
+
+	openings := Table{Title: "Most played openings", Columns: append([]string{"Color", "ECO", "Opening"}, resultsColumns...)}
+	for i, o := range s.Openings {
+		if i >= s.top {
+			break
+		}
+		openings.Rows = append(openings.Rows, append([]string{o.Color, o.ECO, o.Opening}, o.cells()...))
+	}
+
+	terminations := Table{Title: "Results by termination", Columns: []string{"Termination", "Wins", "Draws", "Losses"}}
+	for _, t := range s.Terminations {
+		terminations.Rows = append(terminations.Rows, []string{t.Termination, strconv.Itoa(t.Wins), strconv.Itoa(t.Draws), strconv.Itoa(t.Losses)})
+	}
+
+	wins := Table{Title: "Best wins", Columns: []string{"Date", "Time class", "Color", "Opponent", "Opp. rating", "Rating", "Termination", "URL"}}
+	for i, w := range s.BestWins {
+		if i >= s.top {
+			break
+		}
+		wins.Rows = append(wins.Rows, []string{
+			w.Date.Format("2006-01-02"), w.TimeClass, w.Color, w.Opponent, strconv.Itoa(w.OpponentRating), strconv.Itoa(w.Rating), w.Termination, w.URL,
+		})
+	}
+
+	return []Table{overall, timeClasses, openings, terminations, wins}
+}
+
+func sortedKeys(m map[string]*Results) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func round(f float64, decimals int) float64 {
+	p := math.Pow10(decimals)
+	return math.Round(f*p) / p
+}
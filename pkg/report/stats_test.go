@@ -0,0 +1,122 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"strings"
+	"testing"
+)
+
+// game returns a game between white and black, results are chess.com's result codes
+func game(white, black string, whiteRating, blackRating int, whiteResult, blackResult, timeClass, moves string) model.ChesscomGame {
+	g := model.ChesscomGame{
+		URL:       "https://www.chess.com/game/live/" + white + "-" + black + "-" + whiteResult,
+		PGN:       "[White \"" + white + "\"]\n[Black \"" + black + "\"]\n\n" + moves + " *",
+		EndTime:   1625400000,
+		TimeClass: timeClass,
+		Rules:     "chess",
+		White:     model.ChesscomPlayerInfo{Username: white, Rating: whiteRating, Result: whiteResult},
+		Black:     model.ChesscomPlayerInfo{Username: black, Rating: blackRating, Result: blackResult},
+	}
+	return g
+}
+
+func sampleGames() []model.ChesscomGame {
+	games := []model.ChesscomGame{
+		game("erik", "hikaru", 1500, 3200, "win", "resigned", "blitz", "1. e4 c5"),
+		game("erik", "magnus", 1510, 2900, "agreed", "agreed", "blitz", "1. e4 c5"),
+		game("alice", "erik", 1600, 1490, "timeout", "win", "rapid", "1. d4 d5"),
+		game("bob", "erik", 1400, 1500, "win", "checkmated", "rapid", "1. e4 e5"),
+		game("bob", "alice", 1400, 1600, "win", "checkmated", "rapid", "1. e4 e5"),
+	}
+	games[0].Accuracies.White = 90
+	games[0].Accuracies.Black = 70
+	games[2].Accuracies.White = 60
+	games[2].Accuracies.Black = 80
+	return games
+}
+
+func TestStats(t *testing.T) {
+	s := NewStats("Erik", 1)
+	for i, g := range sampleGames() {
+		added, err := s.Add(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if added != (i < 4) {
+			t.Errorf("Add() game %d = %v", i, added)
+		}
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "games", got: s.Overall.Games, want: 4},
+		{name: "wins", got: s.Overall.Wins, want: 2},
+		{name: "draws", got: s.Overall.Draws, want: 1},
+		{name: "losses", got: s.Overall.Losses, want: 1},
+		{name: "score", got: s.Overall.Score, want: 62.5},
+		{name: "opponent rating", got: s.Overall.OpponentRating, want: (3200 + 2900 + 1600 + 1400) / 4},
+		{name: "performance", got: s.Overall.Performance, want: (3200+2900+1600+1400)/4 + 100},
+		{name: "accuracy games", got: s.Overall.AccuracyGames, want: 2},
+		{name: "accuracy", got: s.Overall.Accuracy, want: 85.0},
+		{name: "opponent accuracy", got: s.Overall.OpponentAccuracy, want: 65.0},
+		{name: "white games", got: s.ByColor[model.ColorWhite].Games, want: 2},
+		{name: "rapid losses", got: s.ByTimeClass["rapid"].Losses, want: 1},
+		{name: "top opening", got: s.Openings[0].Opening, want: "Sicilian Defense"},
+		{name: "best win", got: s.BestWins[0].Opponent, want: "hikaru"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	terminations := make(map[string]TerminationResults)
+	for _, tr := range s.Terminations {
+		terminations[tr.Termination] = *tr
+	}
+	if terminations["resigned"].Wins != 1 || terminations["timeout"].Wins != 1 || terminations["checkmated"].Losses != 1 || terminations["agreed"].Draws != 1 {
+		t.Errorf("unexpected terminations %+v", terminations)
+	}
+
+	tables := s.Tables()
+	if len(tables[2].Rows) != 1 || len(tables[4].Rows) != 1 {
+		t.Errorf("tables are not limited to the top entries")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	s := NewStats("erik", 0)
+	for _, g := range sampleGames() {
+		if _, err := s.Add(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		format   Format
+		contains []string
+	}{
+		{format: FormatText, contains: []string{"Statistics of erik\n==================\n", "\nBy time class\n", "  blitz       2      1  1  0  75.0%"}},
+		{format: FormatMarkdown, contains: []string{"# Statistics of erik\n", "## Best wins\n\n| Date | Time class |", "| --- | --- |", "| blitz | 2 | 1 | 1 | 0 | 75.0% |"}},
+		{format: FormatJSON, contains: []string{`"username": "erik"`, `"by_time_class": {`}},
+	}
+	for _, tt := range tests {
+		buf := bytes.Buffer{}
+		if err := Write(&buf, s, tt.format); err != nil {
+			t.Fatalf("Write(%s) error = %v", tt.format, err)
+		}
+		for _, c := range tt.contains {
+			if !strings.Contains(buf.String(), c) {
+				t.Errorf("Write(%s) output does not contain %q:\n%s", tt.format, c, buf.String())
+			}
+		}
+		if tt.format == FormatJSON && !json.Valid(buf.Bytes()) {
+			t.Errorf("Write(%s) output is not valid JSON", tt.format)
+		}
+	}
+}
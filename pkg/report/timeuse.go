@@ -0,0 +1,439 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/pgn"
+	"github.com/notnil/chess"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Game phases
+const (
+	PhaseOpening    = "opening"
+	PhaseMiddlegame = "middlegame"
+	PhaseEndgame    = "endgame"
+)
+
+// Phases lists the game phases in order
+var Phases = []string{PhaseOpening, PhaseMiddlegame, PhaseEndgame}
+
+const (
+	// openingMoves is the number of moves considered as the opening
+	openingMoves = 10
+	// endgameMaterial is the total non-pawn material of both sides (in pawns) under which the endgame starts
+	endgameMaterial = 26
+	// timeTroubleRatio is the share of the base time under which a player is in time trouble
+	timeTroubleRatio = 0.1
+	// aheadMaterial is the material advantage (in pawns) from which a player is considered ahead
+	aheadMaterial = 2
+)
+
+// MoveTimeBucket is a range of move times of the distribution, from Min (inclusive) to Max (exclusive, 0 if unbounded)
+type MoveTimeBucket struct {
+	Label string        `json:"label"`
+	Min   time.Duration `json:"-"`
+	Max   time.Duration `json:"-"`
+	Moves int           `json:"moves"`
+	// Share is the percentage of moves in the bucket
+	Share float64 `json:"share"`
+}
+
+// newMoveTimeBuckets returns the empty buckets of the move time distribution
+func newMoveTimeBuckets() []MoveTimeBucket {
+	bounds := []time.Duration{0, time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second,
+		30 * time.Second, time.Minute, 2 * time.Minute, 5 * time.Minute}
+	labels := []string{"<1s", "1-2s", "2-5s", "5-10s", "10-30s", "30s-1m", "1-2m", "2-5m", ">=5m"}
+	buckets := make([]MoveTimeBucket, len(bounds))
+	for i := range bounds {
+		buckets[i] = MoveTimeBucket{Label: labels[i], Min: bounds[i]}
+		if i+1 < len(bounds) {
+			buckets[i].Max = bounds[i+1]
+		}
+	}
+	return buckets
+}
+
+// PhaseTime is the time spent in a game phase
+type PhaseTime struct {
+	Moves int `json:"moves"`
+	// Average is the average time per move in seconds
+	Average float64 `json:"average"`
+
+	total time.Duration
+}
+
+func (p *PhaseTime) add(d time.Duration) {
+	p.Moves++
+	p.total += d
+	p.Average = round(p.total.Seconds()/float64(p.Moves), 1)
+}
+
+// GameTimeUse is the player's time use in a game, times are in seconds
+type GameTimeUse struct {
+	URL         string                `json:"url"`
+	Date        time.Time             `json:"date"`
+	TimeClass   string                `json:"time_class"`
+	TimeControl string                `json:"time_control"`
+	Color       string                `json:"color"`
+	Result      string                `json:"result"`
+	ResultCode  string                `json:"result_code"`
+	Moves       int                   `json:"moves"`
+	TotalTime   float64               `json:"total_time"`
+	Average     float64               `json:"average"`
+	Longest     float64               `json:"longest"`
+	Phases      map[string]*PhaseTime `json:"phases"`
+	FinalClock  float64               `json:"final_clock"`
+	// TimeTroubleMoves is the number of moves played with less than 10% of the base time left
+	TimeTroubleMoves int  `json:"time_trouble_moves"`
+	TimeTrouble      bool `json:"time_trouble"`
+	// Material is the player's material advantage in pawns at the end of the game, when the game could be replayed
+	Material *int `json:"material,omitempty"`
+	// LostOnTimeAhead is true if the player lost on time while ahead in material
+	LostOnTimeAhead bool `json:"lost_on_time_ahead"`
+
+	moveTimes []time.Duration
+}
+
+// TimeUseSummary aggregates the time use of the games of a time class, times are in seconds
+type TimeUseSummary struct {
+	TimeClass string                `json:"time_class"`
+	Games     int                   `json:"games"`
+	Moves     int                   `json:"moves"`
+	Average   float64               `json:"average"`
+	Phases    map[string]*PhaseTime `json:"phases"`
+	// TimeTroubleGames is the number of games in which the player got in time trouble
+	TimeTroubleGames int `json:"time_trouble_games"`
+	// TimeTroubleRate is the percentage of games in which the player got in time trouble
+	TimeTroubleRate    float64          `json:"time_trouble_rate"`
+	TimeoutLosses      int              `json:"timeout_losses"`
+	TimeoutLossesAhead int              `json:"timeout_losses_ahead"`
+	Distribution       []MoveTimeBucket `json:"distribution"`
+
+	total time.Duration
+}
+
+func (s *TimeUseSummary) add(g *GameTimeUse) {
+	s.Games++
+	for _, d := range g.moveTimes {
+		s.Moves++
+		s.total += d
+		for i := range s.Distribution {
+			b := &s.Distribution[i]
+			if d >= b.Min && (b.Max == 0 || d < b.Max) {
+				b.Moves++
+			}
+		}
+	}
+	for _, phase := range Phases {
+		if p, ok := g.Phases[phase]; ok {
+			sp := s.Phases[phase]
+			sp.Moves += p.Moves
+			sp.total += p.total
+			sp.Average = round(sp.total.Seconds()/float64(sp.Moves), 1)
+		}
+	}
+	if g.TimeTrouble {
+		s.TimeTroubleGames++
+	}
+	if g.Result == model.OutcomeLoss && g.ResultCode == "timeout" {
+		s.TimeoutLosses++
+	}
+	if g.LostOnTimeAhead {
+		s.TimeoutLossesAhead++
+	}
+
+	if s.Moves > 0 {
+		s.Average = round(s.total.Seconds()/float64(s.Moves), 1)
+		for i := range s.Distribution {
+			s.Distribution[i].Share = round(100*float64(s.Distribution[i].Moves)/float64(s.Moves), 1)
+		}
+	}
+	s.TimeTroubleRate = round(100*float64(s.TimeTroubleGames)/float64(s.Games), 1)
+}
+
+// TimeUse is the player's time management computed from the clocks of live games
+type TimeUse struct {
+	Username string            `json:"username"`
+	Summary  []*TimeUseSummary `json:"summary"`
+	Games    []*GameTimeUse    `json:"games"`
+}
+
+// NewTimeUse returns an empty time use report for username
+func NewTimeUse(username string) *TimeUse {
+	return &TimeUse{Username: username}
+}
+
+// Add analyzes a game, it returns false if the player did not take part in it
+// or if it has no clocks, such as daily games
+func (t *TimeUse) Add(game model.ChesscomGame) (bool, error) {
+	color := game.PlayerColor(t.Username)
+	if color == "" {
+		return false, nil
+	}
+	p, err := pgn.Parse(game.PGN)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse PGN of game %s, err=%v", game.URL, err)
+	}
+	g, ok := newGameTimeUse(game, p, color)
+	if !ok {
+		return false, nil
+	}
+
+	t.Games = append(t.Games, g)
+	t.summary(g.TimeClass).add(g)
+	return true, nil
+}
+
+func (t *TimeUse) summary(timeClass string) *TimeUseSummary {
+	for _, s := range t.Summary {
+		if s.TimeClass == timeClass {
+			return s
+		}
+	}
+	s := &TimeUseSummary{TimeClass: timeClass, Phases: make(map[string]*PhaseTime), Distribution: newMoveTimeBuckets()}
+	for _, phase := range Phases {
+		s.Phases[phase] = &PhaseTime{}
+	}
+	t.Summary = append(t.Summary, s)
+	sort.Slice(t.Summary, func(i, j int) bool { return t.Summary[i].TimeClass < t.Summary[j].TimeClass })
+	return s
+}
+
+// newGameTimeUse computes the time spent on each of the player's moves from the clocks:
+// the previous clock (the base time for the first move) minus the clock after the move plus the increment
+func newGameTimeUse(game model.ChesscomGame, p *pgn.Game, color string) (*GameTimeUse, bool) {
+	base, increment, ok := pgn.TimeControl(p.Tag("TimeControl"))
+	if !ok {
+		return nil, false
+	}
+	white := color == model.ColorWhite
+	player := game.Player(color)
+	g := &GameTimeUse{
+		URL:         game.URL,
+		Date:        time.Unix(game.EndTime, 0).UTC(),
+		TimeClass:   game.TimeClass,
+		TimeControl: p.Tag("TimeControl"),
+		Color:       color,
+		Result:      player.Outcome(),
+		ResultCode:  player.Result,
+		Phases:      make(map[string]*PhaseTime),
+	}
+
+	// Positions are used to find the endgame and the material balance, games which cannot be replayed
+	// (variants or illegal moves) only have the opening and middlegame phases
+	positions, _ := p.Positions()
+	endgame := false
+	previous := base
+	var longest time.Duration
+	for i, m := range p.Moves {
+		if positions != nil && !endgame && pieceMaterial(positions[i]) <= endgameMaterial {
+			endgame = true
+		}
+		if m.White != white {
+			continue
+		}
+		clock, ok := m.Clock()
+		if !ok {
+			continue
+		}
+		d := previous - clock + increment
+		if d < 0 {
+			d = 0
+		}
+		previous = clock
+
+		phase := PhaseMiddlegame
+		switch {
+		case endgame:
+			phase = PhaseEndgame
+		case m.Number <= openingMoves:
+			phase = PhaseOpening
+		}
+		ph, ok := g.Phases[phase]
+		if !ok {
+			ph = &PhaseTime{}
+			g.Phases[phase] = ph
+		}
+		ph.add(d)
+
+		g.moveTimes = append(g.moveTimes, d)
+		if d > longest {
+			longest = d
+		}
+		if float64(clock) < timeTroubleRatio*float64(base) {
+			g.TimeTroubleMoves++
+		}
+	}
+	if len(g.moveTimes) == 0 {
+		return nil, false
+	}
+
+	var total time.Duration
+	for _, d := range g.moveTimes {
+		total += d
+	}
+	g.Moves = len(g.moveTimes)
+	g.TotalTime = round(total.Seconds(), 1)
+	g.Average = round(total.Seconds()/float64(g.Moves), 1)
+	g.Longest = round(longest.Seconds(), 1)
+	g.FinalClock = round(previous.Seconds(), 1)
+	g.TimeTrouble = g.TimeTroubleMoves > 0
+	if positions != nil {
+		c := chess.White
+		if !white {
+			c = chess.Black
+		}
+		material := materialBalance(positions[len(positions)-1], c)
+		g.Material = &material
+		g.LostOnTimeAhead = g.Result == model.OutcomeLoss && g.ResultCode == "timeout" && material >= aheadMaterial
+	}
+	return g, true
+}
+
+// pieceValues are the usual piece values in pawns
+var pieceValues = map[chess.PieceType]int{
+	chess.Pawn:   1,
+	chess.Knight: 3,
+	chess.Bishop: 3,
+	chess.Rook:   5,
+	chess.Queen:  9,
+}
+
+// pieceMaterial returns the non-pawn material of both sides
+func pieceMaterial(pos *chess.Position) int {
+	material := 0
+	for _, piece := range pos.Board().SquareMap() {
+		if piece.Type() != chess.Pawn {
+			material += pieceValues[piece.Type()]
+		}
+	}
+	return material
+}
+
+// materialBalance returns the material of color minus the material of the other side
+func materialBalance(pos *chess.Position, color chess.Color) int {
+	balance := 0
+	for _, piece := range pos.Board().SquareMap() {
+		if piece.Color() == color {
+			balance += pieceValues[piece.Type()]
+		} else {
+			balance -= pieceValues[piece.Type()]
+		}
+	}
+	return balance
+}
+
+// MarshalJSON sorts games by date before they are encoded
+func (t *TimeUse) MarshalJSON() ([]byte, error) {
+	t.sort()
+	type timeUse TimeUse
+	return json.Marshal((*timeUse)(t))
+}
+
+func (t *TimeUse) sort() {
+	sort.SliceStable(t.Games, func(i, j int) bool { return t.Games[i].Date.Before(t.Games[j].Date) })
+}
+
+// Title returns the title of the report
+func (t *TimeUse) Title() string {
+	return fmt.Sprintf("Time management of %s", t.Username)
+}
+
+// Tables returns the time use per time class, per phase, the distribution of move times
+// and the games lost on time while ahead in material
+func (t *TimeUse) Tables() []Table {
+	t.sort()
+
+	summary := Table{
+		Title:   "By time class",
+		Columns: []string{"Time class", "Games", "Moves", "Avg (s)", "Time trouble", "Timeout losses", "Lost on time ahead"},
+	}
+	phases := Table{Title: "Average time per move by phase (s)", Columns: append([]string{"Time class"}, Phases...)}
+	distribution := Table{Title: "Move times", Columns: []string{"Time class"}}
+	for _, b := range newMoveTimeBuckets() {
+		distribution.Columns = append(distribution.Columns, b.Label)
+	}
+
+	for _, s := range t.Summary {
+		summary.Rows = append(summary.Rows, []string{
+			s.TimeClass, strconv.Itoa(s.Games), strconv.Itoa(s.Moves), fmt.Sprintf("%.1f", s.Average),
+			fmt.Sprintf("%d (%.1f%%)", s.TimeTroubleGames, s.TimeTroubleRate),
+			strconv.Itoa(s.TimeoutLosses), strconv.Itoa(s.TimeoutLossesAhead),
+		})
+
+		row := []string{s.TimeClass}
+		for _, phase := range Phases {
+			p := s.Phases[phase]
+			if p.Moves == 0 {
+				row = append(row, "-")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%.1f (%d)", p.Average, p.Moves))
+		}
+		phases.Rows = append(phases.Rows, row)
+
+		row = []string{s.TimeClass}
+		for _, b := range s.Distribution {
+			row = append(row, fmt.Sprintf("%.1f%%", b.Share))
+		}
+		distribution.Rows = append(distribution.Rows, row)
+	}
+
+	ahead := Table{
+		Title:   "Lost on time while ahead in material",
+		Columns: []string{"Date", "Time class", "Color", "Material", "Moves", "URL"},
+	}
+	for _, g := range t.Games {
+		if g.LostOnTimeAhead {
+			ahead.Rows = append(ahead.Rows, []string{
+				g.Date.Format("2006-01-02"), g.TimeClass, g.Color, fmt.Sprintf("%+d", *g.Material), strconv.Itoa(g.Moves), g.URL,
+			})
+		}
+	}
+
+	return []Table{summary, phases, distribution, ahead}
+}
+
+// WriteCSV writes the time use of each game, one row per game
+func (t *TimeUse) WriteCSV(w io.Writer) error {
+	t.sort()
+	cw := csv.NewWriter(w)
+	header := []string{"url", "date", "time_class", "time_control", "color", "result", "result_code", "moves",
+		"total_time", "average", "longest", "final_clock"}
+	for _, phase := range Phases {
+		header = append(header, phase+"_moves", phase+"_average")
+	}
+	header = append(header, "time_trouble_moves", "material", "lost_on_time_ahead")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	float := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	for _, g := range t.Games {
+		row := []string{g.URL, g.Date.Format(time.RFC3339), g.TimeClass, g.TimeControl, g.Color, g.Result, g.ResultCode,
+			strconv.Itoa(g.Moves), float(g.TotalTime), float(g.Average), float(g.Longest), float(g.FinalClock)}
+		for _, phase := range Phases {
+			if p, ok := g.Phases[phase]; ok {
+				row = append(row, strconv.Itoa(p.Moves), float(p.Average))
+			} else {
+				row = append(row, "0", "")
+			}
+		}
+		material := ""
+		if g.Material != nil {
+			material = strconv.Itoa(*g.Material)
+		}
+		row = append(row, strconv.Itoa(g.TimeTroubleMoves), material, strconv.FormatBool(g.LostOnTimeAhead))
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
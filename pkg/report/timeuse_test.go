@@ -0,0 +1,79 @@
+package report
+
+import (
+	"bytes"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"strings"
+	"testing"
+)
+
+func clockGame(url, timeControl, setup, moves, whiteResult, blackResult string) model.ChesscomGame {
+	return model.ChesscomGame{
+		URL:       url,
+		PGN:       "[TimeControl \"" + timeControl + "\"]\n" + setup + "\n" + moves + " *",
+		EndTime:   1625400000,
+		TimeClass: "bullet",
+		White:     model.ChesscomPlayerInfo{Username: "erik", Rating: 1500, Result: whiteResult},
+		Black:     model.ChesscomPlayerInfo{Username: "hikaru", Rating: 3200, Result: blackResult},
+	}
+}
+
+func TestTimeUse(t *testing.T) {
+	games := []model.ChesscomGame{
+		clockGame("1", "60+1", "",
+			"1. e4 {[%clk 0:00:59]} e5 {[%clk 0:00:58]} 2. Nf3 {[%clk 0:00:55]} Nc6 {[%clk 0:00:50]} 3. Bc4 {[%clk 0:00:04]}",
+			"win", "resigned"),
+		clockGame("2", "1/86400", "", "1. e4 {[%clk 23:59:00]}", "win", "resigned"),
+		clockGame("3", "60", "[SetUp \"1\"]\n[FEN \"4k3/8/8/8/8/8/8/R3K3 w - - 0 1\"]\n",
+			"1. Ra2 {[%clk 0:00:30]} Kd7 {[%clk 0:00:50]} 2. Ra3 {[%clk 0:00:00.5]}",
+			"timeout", "win"),
+	}
+	tu := NewTimeUse("erik")
+	for i, g := range games {
+		added, err := tu.Add(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if added != (i != 1) {
+			t.Errorf("Add() game %d = %v", i, added)
+		}
+	}
+
+	first, last, s := tu.Games[0], tu.Games[1], tu.Summary[0]
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "moves", got: first.Moves, want: 3},
+		{name: "total time", got: first.TotalTime, want: 59.0},
+		{name: "longest", got: first.Longest, want: 52.0},
+		{name: "opening moves", got: first.Phases[PhaseOpening].Moves, want: 3},
+		{name: "time trouble moves", got: first.TimeTroubleMoves, want: 1},
+		{name: "final clock", got: first.FinalClock, want: 4.0},
+		{name: "lost on time ahead", got: last.LostOnTimeAhead, want: true},
+		{name: "material", got: *last.Material, want: 5},
+		{name: "endgame average", got: last.Phases[PhaseEndgame].Average, want: 29.8},
+		{name: "summary games", got: s.Games, want: 2},
+		{name: "summary moves", got: s.Moves, want: 5},
+		{name: "time trouble rate", got: s.TimeTroubleRate, want: 100.0},
+		{name: "timeout losses ahead", got: s.TimeoutLossesAhead, want: 1},
+		{name: "2-5s moves", got: s.Distribution[2].Moves, want: 1},
+		{name: "30s-1m moves", got: s.Distribution[5].Moves, want: 2},
+		{name: "10-30s moves", got: s.Distribution[4].Moves, want: 1},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err := Write(&buf, tu, FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[2], ",0,,0,,2,29.8,1,5,true") {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,157 @@
+package widget
+
+import (
+	"fmt"
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+	"image"
+	"image/color"
+	"math"
+)
+
+// ChartSeries is a named line of a LineChart, X values are expected in ascending order
+type ChartSeries struct {
+	Name  string
+	Color color.NRGBA
+	X     []float64
+	Y     []float64
+}
+
+// LineChart draws series as lines with their legend and the range of their values
+type LineChart struct {
+	Series []ChartSeries
+	// FormatX formats the min and max X values displayed below the chart
+	FormatX func(x float64) string
+
+	theme *material.Theme
+}
+
+// ChartColors are the colors given to successive series
+var ChartColors = []color.NRGBA{
+	{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff},
+	{R: 0xff, G: 0x7f, B: 0x0e, A: 0xff},
+	{R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff},
+	{R: 0xd6, G: 0x27, B: 0x28, A: 0xff},
+	{R: 0x94, G: 0x67, B: 0xbd, A: 0xff},
+}
+
+func NewLineChart(th *material.Theme) *LineChart {
+	return &LineChart{theme: th}
+}
+
+// bounds returns the min and max of all the series' values
+func (c *LineChart) bounds() (minX, maxX, minY, maxY float64, ok bool) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	for _, s := range c.Series {
+		for i := range s.X {
+			minX, maxX = math.Min(minX, s.X[i]), math.Max(maxX, s.X[i])
+			minY, maxY = math.Min(minY, s.Y[i]), math.Max(maxY, s.Y[i])
+		}
+	}
+	return minX, maxX, minY, maxY, !math.IsInf(minX, 1)
+}
+
+func (c *LineChart) Layout(gtx layout.Context) layout.Dimensions {
+	minX, maxX, minY, maxY, ok := c.bounds()
+	if !ok {
+		return layout.Dimensions{Size: gtx.Constraints.Min}
+	}
+	formatX := c.FormatX
+	if formatX == nil {
+		formatX = func(x float64) string { return fmt.Sprintf("%g", x) }
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(c.legendLayout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Vertical, Alignment: layout.End}.Layout(gtx,
+						layout.Rigid(c.label(fmt.Sprintf("%.0f", maxY))),
+						layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+							return layout.Dimensions{Size: image.Point{Y: gtx.Constraints.Min.Y}}
+						}),
+						layout.Rigid(c.label(fmt.Sprintf("%.0f", minY))),
+					)
+				}),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					c.drawLines(gtx, minX, maxX, minY, maxY)
+					return layout.Dimensions{Size: gtx.Constraints.Max}
+				}),
+			)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween}.Layout(gtx,
+				layout.Rigid(c.label(formatX(minX))),
+				layout.Rigid(c.label(formatX(maxX))),
+			)
+		}),
+	)
+}
+
+func (c *LineChart) label(txt string) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		lbl := material.Label(c.theme, unit.Dp(12), txt)
+		lbl.Font.Style = text.Italic
+		return lbl.Layout(gtx)
+	}
+}
+
+func (c *LineChart) legendLayout(gtx layout.Context) layout.Dimensions {
+	children := make([]layout.FlexChild, 0, 2*len(c.Series))
+	for _, s := range c.Series {
+		s := s
+		children = append(children,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				lbl := material.Label(c.theme, unit.Dp(13), s.Name)
+				lbl.Color = s.Color
+				return lbl.Layout(gtx)
+			}),
+			layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+		)
+	}
+	return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, children...)
+}
+
+// drawLines draws the series scaled to the available space, the Y axis going upwards
+func (c *LineChart) drawLines(gtx layout.Context, minX, maxX, minY, maxY float64) {
+	size := gtx.Constraints.Max
+	border := clip.Rect{Max: size}.Op()
+	paint.FillShape(gtx.Ops, color.NRGBA{A: 0x10}, border)
+	defer op.Save(gtx.Ops).Load()
+	border.Add(gtx.Ops)
+
+	width, height := float64(size.X), float64(size.Y)
+	point := func(x, y float64) f32.Point {
+		px, py := width/2, height/2
+		if maxX > minX {
+			px = (x - minX) / (maxX - minX) * width
+		}
+		if maxY > minY {
+			py = height - (y-minY)/(maxY-minY)*height
+		}
+		return f32.Pt(float32(px), float32(py))
+	}
+
+	for _, s := range c.Series {
+		if len(s.X) == 0 {
+			continue
+		}
+		var path clip.Path
+		path.Begin(gtx.Ops)
+		path.MoveTo(point(s.X[0], s.Y[0]))
+		for i := 1; i < len(s.X); i++ {
+			path.LineTo(point(s.X[i], s.Y[i]))
+		}
+		stroke := clip.Stroke{Path: path.End(), Style: clip.StrokeStyle{Width: float32(gtx.Px(unit.Dp(1.5)))}}
+		paint.FillShape(gtx.Ops, s.Color, stroke.Op())
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"gioui.org/app"
+	"gioui.org/layout"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/nmaupu/chesscom_exporter/pkg/api/chesscom"
+	"github.com/nmaupu/chesscom_exporter/pkg/model"
+	"github.com/nmaupu/chesscom_exporter/pkg/report"
+	mywidget "github.com/nmaupu/chesscom_exporter/pkg/ui/widget"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	tabExport  = "export"
+	tabStats   = "stats"
+	tabRatings = "ratings"
+	tabTimeUse = "timeuse"
+)
+
+// tabs lists the tabs displayed below the archives list
+var tabs = []struct {
+	key   string
+	label string
+}{
+	{key: tabExport, label: "Export"},
+	{key: tabStats, label: "Statistics"},
+	{key: tabRatings, label: "Ratings"},
+	{key: tabTimeUse, label: "Time management"},
+}
+
+var (
+	currentTab = &widget.Enum{Value: tabExport}
+
+	statsBtn        = new(widget.Clickable)
+	statsInProgress bool
+	statsStatus     string
+	statsLines      []string
+	statsList       = &widget.List{List: layout.List{Axis: layout.Vertical}}
+
+	ratingsBtn        = new(widget.Clickable)
+	ratingsInProgress bool
+	ratingsStatus     string
+	ratingsLines      []string
+	ratingsList       = &widget.List{List: layout.List{Axis: layout.Vertical}}
+	ratingsChart      *mywidget.LineChart
+
+	timeUseBtn        = new(widget.Clickable)
+	timeUseInProgress bool
+	timeUseStatus     string
+	timeUseLines      []string
+	timeUseList       = &widget.List{List: layout.List{Axis: layout.Vertical}}
+)
+
+func tabsLayout(gtx C) D {
+	children := make([]layout.FlexChild, 0, 2*len(tabs))
+	for _, t := range tabs {
+		t := t
+		children = append(children,
+			layout.Rigid(material.RadioButton(theme, currentTab, t.key, t.label).Layout),
+			layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+		)
+	}
+	return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx, children...)
+}
+
+func statsLayout(gtx C) D {
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx C) D {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Rigid(func(gtx C) D {
+					if statsInProgress || !archiveListWidget.AtLeastOneSelected() {
+						gtx = gtx.Disabled()
+					}
+					return material.Button(theme, statsBtn, "Compute statistics").Layout(gtx)
+				}),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+				layout.Rigid(func(gtx C) D {
+					lbl := material.Label(theme, unit.Dp(16), statsStatus)
+					lbl.Font.Style = text.Italic
+					return lbl.Layout(gtx)
+				}),
+			)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(5)}.Layout),
+		layout.Flexed(1, func(gtx C) D {
+			return reportLinesLayout(gtx, statsList, statsLines)
+		}),
+	)
+}
+
+func ratingsLayout(gtx C) D {
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx C) D {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Rigid(func(gtx C) D {
+					if ratingsInProgress || !archiveListWidget.AtLeastOneSelected() {
+						gtx = gtx.Disabled()
+					}
+					return material.Button(theme, ratingsBtn, "Compute rating history").Layout(gtx)
+				}),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+				layout.Rigid(func(gtx C) D {
+					lbl := material.Label(theme, unit.Dp(16), ratingsStatus)
+					lbl.Font.Style = text.Italic
+					return lbl.Layout(gtx)
+				}),
+			)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(5)}.Layout),
+		layout.Flexed(2, func(gtx C) D {
+			if ratingsChart == nil {
+				return D{}
+			}
+			return ratingsChart.Layout(gtx)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(5)}.Layout),
+		layout.Flexed(1, func(gtx C) D {
+			return reportLinesLayout(gtx, ratingsList, ratingsLines)
+		}),
+	)
+}
+
+func timeUseLayout(gtx C) D {
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx C) D {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Rigid(func(gtx C) D {
+					if timeUseInProgress || !archiveListWidget.AtLeastOneSelected() {
+						gtx = gtx.Disabled()
+					}
+					return material.Button(theme, timeUseBtn, "Analyze time management").Layout(gtx)
+				}),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+				layout.Rigid(func(gtx C) D {
+					lbl := material.Label(theme, unit.Dp(16), timeUseStatus)
+					lbl.Font.Style = text.Italic
+					return lbl.Layout(gtx)
+				}),
+			)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(5)}.Layout),
+		layout.Flexed(1, func(gtx C) D {
+			return reportLinesLayout(gtx, timeUseList, timeUseLines)
+		}),
+	)
+}
+
+// reportLinesLayout lays out a report rendered as text with a monospace font
+func reportLinesLayout(gtx C, list *widget.List, lines []string) D {
+	return material.List(theme, list).Layout(gtx, len(lines), func(gtx C, i int) D {
+		lbl := material.Label(theme, unit.Dp(13), lines[i])
+		lbl.Font = text.Font{Variant: "Mono"}
+		lbl.MaxLines = 1
+		return lbl.Layout(gtx)
+	})
+}
+
+// computeSelectedArchivesStats computes the statistics of the games of the selected archives
+func computeSelectedArchivesStats(w *app.Window) {
+	statsInProgress = true
+	defer func() {
+		statsInProgress = false
+		w.Invalidate()
+	}()
+
+	username := strings.Trim(usernameLineEditor.Text(), " ")
+	stats := report.NewStats(username, report.DefaultTop)
+	err := fetchSelectedArchives(func(done, total int) {
+		statsStatus = fmt.Sprintf("Fetching archives %d/%d", done, total)
+		w.Invalidate()
+	}, func(game model.ChesscomGame) {
+		if _, err := stats.Add(game); err != nil {
+			log.Printf("an error occurred computing statistics of game %s, err=%v", game.URL, err)
+		}
+	})
+	if err != nil {
+		statsStatus = fmt.Sprintf("Error: %v", err)
+		return
+	}
+
+	statsLines = renderReportLines(stats)
+	statsStatus = fmt.Sprintf("%d games", stats.Overall.Games)
+}
+
+// computeSelectedArchivesRatings computes the daily rating history of the games of the selected archives
+func computeSelectedArchivesRatings(w *app.Window) {
+	ratingsInProgress = true
+	defer func() {
+		ratingsInProgress = false
+		w.Invalidate()
+	}()
+
+	username := strings.Trim(usernameLineEditor.Text(), " ")
+	history := report.NewRatingHistory(username, report.SamplingDay)
+	games := 0
+	err := fetchSelectedArchives(func(done, total int) {
+		ratingsStatus = fmt.Sprintf("Fetching archives %d/%d", done, total)
+		w.Invalidate()
+	}, func(game model.ChesscomGame) {
+		if history.Add(game) {
+			games++
+		}
+	})
+	if err != nil {
+		ratingsStatus = fmt.Sprintf("Error: %v", err)
+		return
+	}
+
+	// Only the summary is displayed as text, the series are drawn
+	ratingsLines = renderReportLines(summaryReport{Report: history})
+	chart := mywidget.NewLineChart(theme)
+	chart.FormatX = func(x float64) string { return time.Unix(int64(x), 0).UTC().Format("2006-01-02") }
+	for i, s := range history.Series {
+		series := mywidget.ChartSeries{Name: s.TimeClass, Color: mywidget.ChartColors[i%len(mywidget.ChartColors)]}
+		for _, p := range s.Points {
+			series.X = append(series.X, float64(p.Time.Unix()))
+			series.Y = append(series.Y, float64(p.Rating))
+		}
+		chart.Series = append(chart.Series, series)
+	}
+	ratingsChart = chart
+	ratingsStatus = fmt.Sprintf("%d games", games)
+}
+
+// computeSelectedArchivesTimeUse analyzes the time management of the games of the selected archives
+func computeSelectedArchivesTimeUse(w *app.Window) {
+	timeUseInProgress = true
+	defer func() {
+		timeUseInProgress = false
+		w.Invalidate()
+	}()
+
+	username := strings.Trim(usernameLineEditor.Text(), " ")
+	tu := report.NewTimeUse(username)
+	err := fetchSelectedArchives(func(done, total int) {
+		timeUseStatus = fmt.Sprintf("Fetching archives %d/%d", done, total)
+		w.Invalidate()
+	}, func(game model.ChesscomGame) {
+		if _, err := tu.Add(game); err != nil {
+			log.Printf("an error occurred analyzing time management of game %s, err=%v", game.URL, err)
+		}
+	})
+	if err != nil {
+		timeUseStatus = fmt.Sprintf("Error: %v", err)
+		return
+	}
+
+	timeUseLines = renderReportLines(tu)
+	timeUseStatus = fmt.Sprintf("%d live games with clocks", len(tu.Games))
+}
+
+// summaryReport keeps the first table of a report only
+type summaryReport struct {
+	report.Report
+}
+
+func (r summaryReport) Tables() []report.Table {
+	tables := r.Report.Tables()
+	if len(tables) > 1 {
+		return tables[:1]
+	}
+	return tables
+}
+
+// renderReportLines renders a report as text lines
+func renderReportLines(r report.Report) []string {
+	buf := bytes.Buffer{}
+	if err := report.WriteText(&buf, r); err != nil {
+		return []string{fmt.Sprintf("Error: %v", err)}
+	}
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
+
+// fetchSelectedArchives fetches the games of all selected archives, calling progress after each archive
+func fetchSelectedArchives(progress func(done, total int), fn func(game model.ChesscomGame)) error {
+	archives := archiveListWidget.GetSelectedArchives().Archives
+	for i, archive := range archives {
+		progress(i, len(archives))
+		res, err := chesscom.GetPlayerMonthlyArchivesByURL(archive.GetURL())
+		if err != nil {
+			return fmt.Errorf("unable to get archive %s, err=%v", archive.GetURL(), err)
+		}
+		for _, game := range res.Games {
+			fn(game)
+		}
+	}
+	progress(len(archives), len(archives))
+	return nil
+}